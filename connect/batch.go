@@ -0,0 +1,125 @@
+package connect
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of URLs GenerateBatch generates at
+// the same time when Connect.BatchConcurrency is not set.
+const DefaultBatchConcurrency = 8
+
+// SessionSpec describes a single personalised Connect URL generated by GenerateBatch.
+type SessionSpec struct {
+	// State is added to the redirect URL as the `state` query parameter.
+	State string
+	// Params are extra query parameters added to the redirect URL, such as a session ID.
+	Params url.Values
+	// Platform overrides the platform of the Connect for this URL.
+	Platform PlatformType
+}
+
+// BatchResult is the outcome of generating the URL for one SessionSpec.
+type BatchResult struct {
+	URL string
+	Err error
+}
+
+// GenerateBatch generates one Connect URL per session spec. The public key,
+// redirect URL and services are validated once for the whole batch; an error
+// is returned if that validation fails. Failures of individual URLs are
+// reported in the result with the same index as their spec.
+//
+// When ctx is done, no further URLs are generated: the URLs not generated yet
+// report the error of ctx, which is also returned with the partial results.
+func (c *Connect) GenerateBatch(ctx context.Context, specs []SessionSpec) ([]BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	servicesJSON, err := c.validatedServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := c.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]BatchResult, len(specs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(specs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i] = BatchResult{Err: err}
+					continue
+				}
+				url, err := c.generateSessionURL(servicesJSON, specs[i])
+				results[i] = BatchResult{URL: url, Err: err}
+			}
+		}()
+	}
+
+send:
+	for i := range specs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			for j := i; j < len(specs); j++ {
+				results[j] = BatchResult{Err: ctx.Err()}
+			}
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, result := range results {
+		if err := ctx.Err(); err != nil && result.Err == err {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (c *Connect) generateSessionURL(servicesJSON string, spec SessionSpec) (string, error) {
+	redirectURL, err := url.Parse(c.RedirectURL)
+	if err != nil {
+		return "", &GandalfError{
+			Message: "Invalid redirect URL",
+			Code:    InvalidRedirectURL,
+		}
+	}
+
+	query := redirectURL.Query()
+	for key, values := range spec.Params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	if spec.State != "" {
+		query.Set("state", spec.State)
+	}
+	redirectURL.RawQuery = query.Encode()
+
+	session := *c
+	if spec.Platform != "" {
+		session.Platform = spec.Platform
+	}
+
+	url, err := session.encodeComponents(servicesJSON, redirectURL.String(), c.PublicKey)
+	if err != nil {
+		return "", &GandalfError{
+			Message: "Encoding Error",
+			Code:    EncodingError,
+		}
+	}
+	return url, nil
+}
@@ -0,0 +1,188 @@
+package connect
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+// testPublicKey is the application public key used by the connect tests.
+const testPublicKey = "0x036518f1c7a10fc77f835becc0aca9916c54505f771c82d87dd5943bb01ba5ca08"
+
+// newTestSauron starts a saurontest server knowing the application of
+// testPublicKey and points SAURON_BASE_URL at it for the rest of the test.
+func newTestSauron(t *testing.T) *saurontest.Server {
+	t.Helper()
+
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps: []saurontest.App{{
+			AppName:   "Example",
+			PublicKey: testPublicKey,
+			IconURL:   "https://example.com/icon.png",
+			GandalfID: 1,
+		}},
+	})
+	t.Cleanup(server.Close)
+
+	baseURL := SAURON_BASE_URL
+	SAURON_BASE_URL = server.URL
+	t.Cleanup(func() { SAURON_BASE_URL = baseURL })
+
+	return server
+}
+
+func TestGenerateBatch(t *testing.T) {
+	server := newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect?campaign=spring",
+		Data: InputData{
+			"netflix": Service{Traits: []string{"rating"}},
+		},
+		BatchConcurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+
+	specs := []SessionSpec{
+		{State: "user-1"},
+		{State: "user-2", Params: url.Values{"session": {"abc"}}},
+		{State: "user-3", Platform: PlatformTypeAndroid},
+	}
+
+	results, err := conn.GenerateBatch(context.Background(), specs)
+	if err != nil {
+		t.Fatalf("GenerateBatch() error = %v", err)
+	}
+	if len(results) != len(specs) {
+		t.Fatalf("GenerateBatch() returned %d results, want %d", len(results), len(specs))
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("GenerateBatch() made %d Sauron requests, want 2", got)
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("results[%d].Err = %v", i, result.Err)
+		}
+
+		// The iOS base URL already carries a query, so the Connect
+		// parameters follow the last '?'.
+		query, err := url.ParseQuery(result.URL[strings.LastIndex(result.URL, "?")+1:])
+		if err != nil {
+			t.Fatalf("results[%d].URL is invalid: %v", i, err)
+		}
+		redirectURL, err := url.Parse(query.Get("redirectUrl"))
+		if err != nil {
+			t.Fatalf("results[%d] redirect URL is invalid: %v", i, err)
+		}
+		if got := redirectURL.Query().Get("state"); got != specs[i].State {
+			t.Errorf("results[%d] state = %q, want %q", i, got, specs[i].State)
+		}
+		if got := redirectURL.Query().Get("campaign"); got != "spring" {
+			t.Errorf("results[%d] campaign = %q, want %q", i, got, "spring")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(query.Get("data"))
+		if err != nil {
+			t.Fatalf("results[%d] data is not base64: %v", i, err)
+		}
+		payload, err := DecodePayload(data)
+		if err != nil {
+			t.Errorf("results[%d] payload is invalid: %v", i, err)
+		} else if payload.Version != 0 {
+			// The Connect app reads the unversioned format.
+			t.Errorf("results[%d] payload version = %d, want unversioned %s", i, payload.Version, data)
+		}
+	}
+
+	if !strings.HasPrefix(results[0].URL, IOS_APP_CLIP_BASE_URL) {
+		t.Errorf("results[0].URL = %s, want iOS base URL", results[0].URL)
+	}
+	if !strings.HasPrefix(results[2].URL, ANDROID_APP_CLIP_BASE_URL) {
+		t.Errorf("results[2].URL = %s, want Android base URL", results[2].URL)
+	}
+	if got := results[1].URL; !strings.Contains(got, url.QueryEscape("session=abc")) {
+		t.Errorf("results[1].URL = %s, want session parameter", got)
+	}
+}
+
+func TestGenerateBatchCanceled(t *testing.T) {
+	newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"uber": true},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := conn.GenerateBatch(ctx, make([]SessionSpec, 3))
+	if err != context.Canceled || results != nil {
+		t.Errorf("GenerateBatch() = %+v, %v, want no results and %v", results, err, context.Canceled)
+	}
+}
+
+// cancelAfter is a context canceled on the first call to Err after the
+// first n calls.
+type cancelAfter struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int32
+	calls  atomic.Int32
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls.Add(1) > c.n {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestGenerateBatchCanceledMidway(t *testing.T) {
+	newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:        testPublicKey,
+		RedirectURL:      "https://example.com/redirect",
+		Data:             InputData{"uber": true},
+		BatchConcurrency: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+
+	// GenerateBatch checks the context once before validating and once
+	// before each URL, so the fourth URL is the first one canceled.
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := &cancelAfter{Context: parent, cancel: cancel, n: 4}
+
+	results, err := conn.GenerateBatch(ctx, make([]SessionSpec, 10))
+	if err != context.Canceled {
+		t.Fatalf("GenerateBatch() error = %v, want %v", err, context.Canceled)
+	}
+	if len(results) != 10 {
+		t.Fatalf("GenerateBatch() returned %d results, want 10", len(results))
+	}
+	for i, result := range results {
+		if i < 3 && (result.Err != nil || result.URL == "") {
+			t.Errorf("results[%d] = %+v, want a URL generated before the cancellation", i, result)
+		}
+		if i >= 3 && (result.Err != context.Canceled || result.URL != "") {
+			t.Errorf("results[%d] = %+v, want %v", i, result, context.Canceled)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Command connect generates Gandalf Connect URLs and QR codes.
+//
+// The request can be given with flags:
+//
+//	go run github.com/gandalf-network/gandalf-sdk-go/connect/cmd/connect \
+//		-public-key 0x... -redirect-url https://example.com \
+//		-services netflix -traits rating -activities watch
+//
+// or read from a YAML or JSON request file with -file, in the format accepted
+// by connect.LoadConfig. Flags override the values read from the file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gandalf-network/gandalf-sdk-go/connect"
+	"github.com/skip2/go-qrcode"
+)
+
+func main() {
+	var (
+		file        string
+		publicKey   string
+		redirectURL string
+		platform    string
+		services    string
+		traits      string
+		activities  string
+		output      string
+		terminal    bool
+	)
+	flag.StringVar(&file, "file", "", "Read the request from a YAML or JSON file")
+	flag.StringVar(&publicKey, "public-key", "", "Set the public key of your application")
+	flag.StringVar(&redirectURL, "redirect-url", "", "Set the URL users are sent to after linking their account")
+	flag.StringVar(&platform, "platform", "", "Set the platform: ios, android or universal")
+	flag.StringVar(&services, "services", "", "Comma-separated list of services to request")
+	flag.StringVar(&traits, "traits", "", "Comma-separated list of traits to request for each service")
+	flag.StringVar(&activities, "activities", "", "Comma-separated list of activities to request for each service")
+	flag.StringVar(&output, "output", "", "Write the QR code to a .png or .svg file")
+	flag.StringVar(&output, "o", "", "Write the QR code to a .png or .svg file")
+	flag.BoolVar(&terminal, "terminal", false, "Render the QR code in the terminal")
+	flag.Parse()
+
+	var config connect.Config
+	if file != "" {
+		conn, err := connect.LoadConfig(file)
+		if err != nil {
+			log.Fatalf("Error loading request file: %v", err)
+		}
+		config = connect.Config{
+			PublicKey:   conn.PublicKey,
+			RedirectURL: conn.RedirectURL,
+			Platform:    conn.Platform,
+			Data:        conn.Data,
+		}
+	}
+
+	if publicKey != "" {
+		config.PublicKey = publicKey
+	}
+	if redirectURL != "" {
+		config.RedirectURL = redirectURL
+	}
+	if platform != "" {
+		platformType, err := connect.ParsePlatform(platform)
+		if err != nil {
+			usageError(err)
+		}
+		config.Platform = platformType
+	}
+	if services != "" {
+		config.Data = buildInputData(splitList(services), splitList(traits), splitList(activities))
+	}
+	if err := checkConfig(config); err != nil {
+		usageError(err)
+	}
+
+	conn, err := connect.NewConnect(config)
+	if err != nil {
+		log.Fatalf("An error occurred with initializing connect: %v", err)
+	}
+
+	url, err := conn.GenerateURL()
+	if err != nil {
+		log.Fatalf("An error occurred generating url: %v", err)
+	}
+	fmt.Println(url)
+
+	if output == "" && !terminal {
+		return
+	}
+
+	qrCode, err := qrcode.New(url, qrcode.Medium)
+	if err != nil {
+		log.Fatalf("An error occurred generating QR Code: %v", err)
+	}
+
+	if terminal {
+		fmt.Print(qrCode.ToSmallString(false))
+	}
+
+	if output != "" {
+		if err := writeQRCode(qrCode, output); err != nil {
+			log.Fatalf("An error occurred writing QR Code: %v", err)
+		}
+		fmt.Printf("QR code saved to %s\n", output)
+	}
+}
+
+// usageError reports an invalid request and exits.
+func usageError(err error) {
+	fmt.Println("Error:", err)
+	flag.Usage()
+	os.Exit(1)
+}
+
+// checkConfig checks that the request read from the flags and the request
+// file has the values NewConnect needs.
+func checkConfig(config connect.Config) error {
+	if config.PublicKey == "" {
+		return fmt.Errorf("public key is required")
+	}
+	if config.RedirectURL == "" {
+		return fmt.Errorf("redirect URL is required")
+	}
+	if len(config.Data) == 0 {
+		return fmt.Errorf("at least one service is required")
+	}
+	return nil
+}
+
+// buildInputData requests the same traits and activities from every service.
+// Services given without traits or activities are simply required.
+func buildInputData(services, traits, activities []string) connect.InputData {
+	data := make(connect.InputData, len(services))
+	for _, name := range services {
+		if len(traits) == 0 && len(activities) == 0 {
+			data[name] = true
+			continue
+		}
+		data[name] = connect.Service{
+			Traits:     traits,
+			Activities: activities,
+		}
+	}
+	return data
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func writeQRCode(qrCode *qrcode.QRCode, filename string) error {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".png":
+		return qrCode.WriteFile(256, filename)
+	case ".svg":
+		return os.WriteFile(filename, []byte(qrCodeSVG(qrCode)), 0644)
+	default:
+		return fmt.Errorf("unsupported QR code format %q, use .png or .svg", filepath.Ext(filename))
+	}
+}
+
+// qrCodeSVG renders the QR code as an SVG image with one unit per module.
+func qrCodeSVG(qrCode *qrcode.QRCode) string {
+	bitmap := qrCode.Bitmap()
+	size := len(bitmap)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size))
+	sb.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#ffffff"/>`, size, size))
+	sb.WriteString(`<path fill="#000000" d="`)
+	for y, row := range bitmap {
+		for x, black := range row {
+			if black {
+				sb.WriteString(fmt.Sprintf("M%d %dh1v1h-1z", x, y))
+			}
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+	sb.WriteString("\n")
+	return sb.String()
+}
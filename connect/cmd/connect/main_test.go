@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gandalf-network/gandalf-sdk-go/connect"
+	"github.com/skip2/go-qrcode"
+)
+
+func TestCheckConfig(t *testing.T) {
+	valid := connect.Config{
+		PublicKey:   "0x036518f1c7a10fc77f835becc0aca9916c54505f771c82d87dd5943bb01ba5ca08",
+		RedirectURL: "https://example.com/redirect",
+		Data:        connect.InputData{"uber": true},
+	}
+
+	tests := []struct {
+		name        string
+		config      func(*connect.Config)
+		expectedErr bool
+	}{
+		{name: "Complete request", config: func(*connect.Config) {}},
+		{name: "Missing public key", config: func(c *connect.Config) { c.PublicKey = "" }, expectedErr: true},
+		{name: "Missing redirect URL", config: func(c *connect.Config) { c.RedirectURL = "" }, expectedErr: true},
+		{name: "Missing services", config: func(c *connect.Config) { c.Data = nil }, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.config(&config)
+			if err := checkConfig(config); (err != nil) != tt.expectedErr {
+				t.Errorf("checkConfig() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestBuildInputData(t *testing.T) {
+	tests := []struct {
+		name       string
+		services   string
+		traits     string
+		activities string
+		expected   connect.InputData
+	}{
+		{
+			name:       "Service with traits and activities",
+			services:   "netflix",
+			traits:     "rating, plan",
+			activities: "watch",
+			expected: connect.InputData{
+				"netflix": connect.Service{Traits: []string{"rating", "plan"}, Activities: []string{"watch"}},
+			},
+		},
+		{
+			name:     "Service without traits or activities",
+			services: "uber,",
+			expected: connect.InputData{"uber": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildInputData(splitList(tt.services), splitList(tt.traits), splitList(tt.activities))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("buildInputData() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	qrCode, err := qrcode.New("https://example.com", qrcode.Medium)
+	if err != nil {
+		t.Fatalf("qrcode.New() error = %v", err)
+	}
+
+	svg := qrCodeSVG(qrCode)
+	if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("qrCodeSVG() returned malformed SVG: %s", svg)
+	}
+	if !strings.Contains(svg, "M4 4h1v1h-1z") {
+		t.Error("qrCodeSVG() is missing the top-left finder pattern")
+	}
+}
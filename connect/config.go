@@ -0,0 +1,223 @@
+package connect
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// configFile is the layout of a Connect request file:
+//
+//	publicKey: ${GANDALF_PUBLIC_KEY}
+//	redirectURL: https://example.com/callback
+//	platform: ios
+//	services:
+//	  netflix:
+//	    traits: [rating]
+//	    activities: [watch]
+//
+// JSON files use the same keys.
+type configFile struct {
+	PublicKey   string                   `yaml:"publicKey"`
+	RedirectURL string                   `yaml:"redirectURL"`
+	Platform    string                   `yaml:"platform"`
+	Services    map[string]serviceConfig `yaml:"services"`
+}
+
+// serviceConfig is either `true` or a list of traits and activities.
+type serviceConfig struct {
+	Required   bool
+	Traits     []string
+	Activities []string
+}
+
+func (s *serviceConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&s.Required); err == nil {
+		return nil
+	}
+
+	var service struct {
+		Traits     []string `yaml:"traits"`
+		Activities []string `yaml:"activities"`
+	}
+	if err := unmarshal(&service); err != nil {
+		return err
+	}
+	s.Required = true
+	s.Traits = service.Traits
+	s.Activities = service.Activities
+	return nil
+}
+
+// LoadConfig reads a Connect request from a YAML or JSON file and returns a
+// Connect ready to generate URLs and QR codes.
+//
+// String values may reference environment variables as $VAR, ${VAR} or
+// ${VAR:-default}, and $$ stands for a literal $. Referencing an unset variable
+// without a default is an error.
+func LoadConfig(path string) (*Connect, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &GandalfError{
+			Message: fmt.Sprintf("Unable to read config %s: %v", path, err),
+			Code:    InvalidConfig,
+		}
+	}
+
+	conn, err := parseConfig(content)
+	if err != nil {
+		if gandalfErr, ok := err.(*GandalfError); ok {
+			gandalfErr.Message = fmt.Sprintf("%s: %s", path, gandalfErr.Message)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func parseConfig(content []byte) (*Connect, error) {
+	var file configFile
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, &GandalfError{
+			Message: fmt.Sprintf("Invalid config: %v", err),
+			Code:    InvalidConfig,
+		}
+	}
+
+	if err := file.expandEnv(); err != nil {
+		return nil, err
+	}
+
+	config, err := file.config()
+	if err != nil {
+		return nil, err
+	}
+	return NewConnect(config)
+}
+
+func (f *configFile) expandEnv() error {
+	var missing []string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			if name == "$" {
+				return "$"
+			}
+			name, fallback, hasFallback := strings.Cut(name, ":-")
+			if value, ok := os.LookupEnv(name); ok && (value != "" || !hasFallback) {
+				return value
+			}
+			if !hasFallback {
+				missing = append(missing, name)
+			}
+			return fallback
+		})
+	}
+
+	f.PublicKey = expand(f.PublicKey)
+	f.RedirectURL = expand(f.RedirectURL)
+	f.Platform = expand(f.Platform)
+	for name, service := range f.Services {
+		for i := range service.Traits {
+			service.Traits[i] = expand(service.Traits[i])
+		}
+		for i := range service.Activities {
+			service.Activities[i] = expand(service.Activities[i])
+		}
+		f.Services[name] = service
+	}
+
+	if len(missing) > 0 {
+		return &GandalfError{
+			Message: fmt.Sprintf("Environment variables %s are not set", strings.Join(missing, ", ")),
+			Code:    InvalidConfig,
+		}
+	}
+	return nil
+}
+
+func (f *configFile) config() (Config, error) {
+	if err := validatePublicKeyFormat(f.PublicKey); err != nil {
+		return Config{}, err
+	}
+
+	if err := validateRedirectURL(f.RedirectURL); err != nil {
+		return Config{}, err
+	}
+
+	platform, err := ParsePlatform(f.Platform)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if len(f.Services) != 1 {
+		return Config{}, &GandalfError{
+			Message: "Exactly one service is required per Connect URL",
+			Code:    InvalidService,
+		}
+	}
+
+	data := make(InputData, len(f.Services))
+	for name, service := range f.Services {
+		if len(service.Traits) == 0 && len(service.Activities) == 0 {
+			if !service.Required {
+				return Config{}, &GandalfError{
+					Message: "At least one service has to be required",
+					Code:    InvalidService,
+				}
+			}
+			data[name] = true
+			continue
+		}
+
+		input := Service{Traits: service.Traits, Activities: service.Activities}
+		if err := validateInputService(input); err != nil {
+			return Config{}, err
+		}
+		data[name] = input
+	}
+
+	return Config{
+		PublicKey:   f.PublicKey,
+		RedirectURL: f.RedirectURL,
+		Platform:    platform,
+		Data:        data,
+	}, nil
+}
+
+// validatePublicKeyFormat checks that the public key is a hex-encoded
+// compressed secp256k1 key, without contacting Sauron.
+func validatePublicKeyFormat(publicKey string) error {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+	if err == nil && len(keyBytes) == btcec.PubKeyBytesLenCompressed {
+		_, err = btcec.ParsePubKey(keyBytes)
+	} else if err == nil {
+		err = fmt.Errorf("expected %d bytes, got %d", btcec.PubKeyBytesLenCompressed, len(keyBytes))
+	}
+
+	if err != nil {
+		return &GandalfError{
+			Message: fmt.Sprintf("Invalid public key: %v", err),
+			Code:    InvalidPublicKey,
+		}
+	}
+	return nil
+}
+
+// ParsePlatform converts a case-insensitive platform name into a PlatformType.
+// An empty name selects PlatformTypeIOS.
+func ParsePlatform(platform string) (PlatformType, error) {
+	switch p := PlatformType(strings.ToLower(platform)); p {
+	case "":
+		return PlatformTypeIOS, nil
+	case PlatformTypeIOS, PlatformTypeAndroid, PlatformUniversal:
+		return p, nil
+	default:
+		return "", &GandalfError{
+			Message: fmt.Sprintf("Unsupported platform %s", platform),
+			Code:    InvalidConfig,
+		}
+	}
+}
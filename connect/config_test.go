@@ -0,0 +1,185 @@
+package connect
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+	"github.com/gandalf-network/gandalf-sdk-go/generated"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("GANDALF_TEST_PUBLIC_KEY", testPublicKey)
+
+	tests := []struct {
+		name        string
+		filename    string
+		content     string
+		expected    *Connect
+		expectedErr error
+	}{
+		{
+			name:     "YAML config",
+			filename: "connect.yaml",
+			content: `
+publicKey: ${GANDALF_TEST_PUBLIC_KEY}
+redirectURL: ${GANDALF_TEST_REDIRECT_URL:-https://example.com/redirect}
+platform: Android
+services:
+  netflix:
+    traits: [rating]
+    activities: [watch]
+`,
+			expected: &Connect{
+				PublicKey:   testPublicKey,
+				RedirectURL: "https://example.com/redirect",
+				Platform:    PlatformTypeAndroid,
+				Data: InputData{
+					"netflix": Service{Traits: []string{"rating"}, Activities: []string{"watch"}},
+				},
+			},
+		},
+		{
+			name:     "JSON config",
+			filename: "connect.json",
+			content:  `{"publicKey": "$GANDALF_TEST_PUBLIC_KEY", "redirectURL": "https://example.com", "services": {"uber": true}}`,
+			expected: &Connect{
+				PublicKey:   testPublicKey,
+				RedirectURL: "https://example.com",
+				Platform:    PlatformTypeIOS,
+				Data:        InputData{"uber": true},
+			},
+		},
+		{
+			name:        "Unset environment variable",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_UNSET_KEY}\nredirectURL: https://example.com\nservices: {uber: true}\n",
+			expectedErr: &GandalfError{Code: InvalidConfig},
+		},
+		{
+			name:        "Unknown field",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_PUBLIC_KEY}\nredirectUrl: https://example.com\nservices: {uber: true}\n",
+			expectedErr: &GandalfError{Code: InvalidConfig},
+		},
+		{
+			name:        "Malformed public key",
+			filename:    "connect.yaml",
+			content:     "publicKey: invalid-public-key\nredirectURL: https://example.com\nservices: {uber: true}\n",
+			expectedErr: &GandalfError{Code: InvalidPublicKey},
+		},
+		{
+			name:        "Invalid redirect URL",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_PUBLIC_KEY}\nredirectURL: example\nservices: {uber: true}\n",
+			expectedErr: &GandalfError{Code: InvalidRedirectURL},
+		},
+		{
+			name:        "Unsupported platform",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_PUBLIC_KEY}\nredirectURL: https://example.com\nplatform: web\nservices: {uber: true}\n",
+			expectedErr: &GandalfError{Code: InvalidConfig},
+		},
+		{
+			name:        "Multiple services",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_PUBLIC_KEY}\nredirectURL: https://example.com\nservices: {uber: true, netflix: true}\n",
+			expectedErr: &GandalfError{Code: InvalidService},
+		},
+		{
+			name:        "Disabled service",
+			filename:    "connect.yaml",
+			content:     "publicKey: ${GANDALF_TEST_PUBLIC_KEY}\nredirectURL: https://example.com\nservices: {uber: false}\n",
+			expectedErr: &GandalfError{Code: InvalidService},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			conn, err := LoadConfig(path)
+			if tt.expectedErr != nil {
+				gandalfErr, ok := err.(*GandalfError)
+				if !ok || gandalfErr.Code != tt.expectedErr.(*GandalfError).Code {
+					t.Fatalf("LoadConfig() error = %v, expectedErr code = %d", err, tt.expectedErr.(*GandalfError).Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(conn, tt.expected) {
+				t.Errorf("LoadConfig() = %+v, want %+v", conn, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigExpandEnv(t *testing.T) {
+	t.Setenv("GANDALF_TEST_HOST", "example.com")
+	t.Setenv("GANDALF_TEST_EMPTY", "")
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "https://$GANDALF_TEST_HOST/redirect", expected: "https://example.com/redirect"},
+		{value: "https://${GANDALF_TEST_HOST}/redirect", expected: "https://example.com/redirect"},
+		{value: "${GANDALF_TEST_EMPTY:-https://example.com}", expected: "https://example.com"},
+		{value: "https://example.com/?price=$$5", expected: "https://example.com/?price=$5"},
+		{value: "https://example.com/?q=$$GANDALF_TEST_HOST", expected: "https://example.com/?q=$GANDALF_TEST_HOST"},
+		{value: "https://example.com/$$$GANDALF_TEST_HOST", expected: "https://example.com/$example.com"},
+		{value: "https://example.com/$", expected: "https://example.com/$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			file := configFile{RedirectURL: tt.value}
+			if err := file.expandEnv(); err != nil {
+				t.Fatalf("expandEnv() error = %v", err)
+			}
+			if file.RedirectURL != tt.expected {
+				t.Errorf("expandEnv() = %q, want %q", file.RedirectURL, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePublicKeyFormatGeneratedKeys(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("NewPrivateKey() error = %v", err)
+		}
+		publicKey := generated.PublicKeyHex(&privKey.ToECDSA().PublicKey)
+		if err := validatePublicKeyFormat(publicKey); err != nil {
+			t.Fatalf("validatePublicKeyFormat(%s) error = %v", publicKey, err)
+		}
+		if publicKey != "0x"+hex.EncodeToString(privKey.PubKey().SerializeCompressed()) {
+			t.Errorf("PublicKeyHex() = %s, want the compressed key", publicKey)
+		}
+	}
+
+	privKey, err := generated.HexToECDSAPrivateKey(saurontest.PrivateKey)
+	if err != nil {
+		t.Fatalf("HexToECDSAPrivateKey() error = %v", err)
+	}
+	publicKey := generated.PublicKeyHex(&privKey.PublicKey)
+	if publicKey != saurontest.TestApp.PublicKey {
+		t.Errorf("PublicKeyHex() = %s, want %s", publicKey, saurontest.TestApp.PublicKey)
+	}
+	if err := validatePublicKeyFormat(publicKey); err != nil {
+		t.Errorf("validatePublicKeyFormat(%s) error = %v", publicKey, err)
+	}
+	if err := validatePublicKeyFormat(publicKey[:len(publicKey)-2]); err == nil {
+		t.Error("validatePublicKeyFormat() of a truncated key expected an error")
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -28,6 +29,9 @@ const (
 	QRCodeGenNotSupported
 	QRCodeNotGenerated
 	EncodingError
+	InvalidPayload
+	InvalidConfig
+	SauronUnavailable
 )
 
 func (e *GandalfError) Error() string {
@@ -43,25 +47,15 @@ func NewConnect(config Config) (*Connect, error) {
 	if config.Platform == "" {
 		config.Platform = PlatformTypeIOS
 	}
-	return &Connect{PublicKey: config.PublicKey, RedirectURL: config.RedirectURL, Data: config.Data, Platform: config.Platform}, nil
+	return &Connect{PublicKey: config.PublicKey, RedirectURL: config.RedirectURL, Data: config.Data, Platform: config.Platform, BatchConcurrency: config.BatchConcurrency}, nil
 }
 
 func (c *Connect) GenerateURL() (string, error) {
-	services, err := runValidation(c.PublicKey, c.RedirectURL, c.Data, c.VerificationStatus)
+	servicesJSON, err := c.validatedServices(context.Background())
 	if err != nil {
 		return "", err
 	}
-
-	servicesJSON := servicesToJSON(services)
-
-	url, err := c.encodeComponents(string(servicesJSON), c.RedirectURL, c.PublicKey)
-	if err != nil {
-		return "", &GandalfError{
-			Message: "Encoding Error",
-			Code: EncodingError,
-		}
-	}
-	return url, nil
+	return c.appClipURL(servicesJSON)
 }
 
 func (c *Connect) GenerateQRCode() (string, error) {
@@ -72,23 +66,70 @@ func (c *Connect) GenerateQRCode() (string, error) {
 		}
 	}
 
-	services, err := runValidation(c.PublicKey, c.RedirectURL, c.Data, c.VerificationStatus)
+	servicesJSON, err := c.validatedServices(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	qrCodeData, err := c.qrCodePNG(servicesJSON)
+	if err != nil {
+		return "", err
+	}
+	return qrCodeDataURI(qrCodeData), nil
+}
+
+// validatedServices validates the Connect and returns its services encoded
+// for the Connect URL. The result can be reused for the URLs and QR codes of
+// every platform.
+func (c *Connect) validatedServices(ctx context.Context) (string, error) {
+	services, err := runValidation(ctx, c.PublicKey, c.RedirectURL, c.Data, c.VerificationStatus)
 	if err != nil {
 		return "", err
 	}
 
-	servicesJSON := servicesToJSON(services)
-	appClipURL, err := c.encodeComponents(string(servicesJSON), c.RedirectURL, c.PublicKey)
+	servicesJSON, err := servicesToJSON(services)
+	if err != nil {
+		return "", err
+	}
+	return string(servicesJSON), nil
+}
+
+// appClipURL returns the Connect URL for the platform of c, from services
+// returned by validatedServices.
+func (c *Connect) appClipURL(servicesJSON string) (string, error) {
+	url, err := c.encodeComponents(servicesJSON, c.RedirectURL, c.PublicKey)
 	if err != nil {
 		return "", &GandalfError{
 			Message: "Encoding Error",
 			Code: EncodingError,
 		}
 	}
+	return url, nil
+}
+
+// qrCodeDataURI returns a PNG image as a data URI.
+func qrCodeDataURI(png []byte) string {
+	return fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(png))
+}
+
+// qrCodePNG returns the QR code of the Connect URL as a 256x256 PNG image,
+// from services returned by validatedServices.
+func (c *Connect) qrCodePNG(servicesJSON string) ([]byte, error) {
+	if c.Data == nil {
+		return nil, &GandalfError{
+			Message: "Invalid input parameters",
+			Code:    QRCodeGenNotSupported,
+		}
+	}
+
+	appClipURL, err := c.appClipURL(servicesJSON)
+	if err != nil {
+		return nil, err
+	}
 
 	qrCode, err := qrcode.New(appClipURL, qrcode.Medium)
 	if err != nil {
-		return "", &GandalfError{
+		return nil, &GandalfError{
 			Message: "QRCode Generation Error",
 			Code:    QRCodeNotGenerated,
 		}
@@ -96,27 +137,27 @@ func (c *Connect) GenerateQRCode() (string, error) {
 
 	qrCodeData, err := qrCode.PNG(256)
 	if err != nil {
-		return "", &GandalfError{
+		return nil, &GandalfError{
 			Message: "QRCode Generation Error",
 			Code:    QRCodeNotGenerated,
 		}
 	}
-	qrCodeURL := fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(qrCodeData))
-	return qrCodeURL, nil
+	return qrCodeData, nil
 }
 
-func introspectSauron() IntrospectionResult {
+func introspectSauron(ctx context.Context) (IntrospectionResult, error) {
 	client := graphqlClient.NewClient(SAURON_BASE_URL)
 	req := graphqlClient.NewRequest(constants.IntrospectionQuery)
 
-	ctx := context.Background()
-
 	var respData IntrospectionResult
 
 	if err := client.Run(ctx, req, &respData); err != nil {
-		log.Fatalf("Error making introspection query: %v", err)
+		return IntrospectionResult{}, &GandalfError{
+			Message: fmt.Sprintf("Error making introspection query: %v", err),
+			Code:    SauronUnavailable,
+		}
 	}
-	return respData
+	return respData, nil
 }
 
 func validateRedirectURL(rawURL string) error {
@@ -130,22 +171,28 @@ func validateRedirectURL(rawURL string) error {
 	return nil
 }
 
-func validatePublicKey(publicKey string) bool {
-	return publicKeyRequest(publicKey)
+func validatePublicKey(ctx context.Context, publicKey string) error {
+	return publicKeyRequest(ctx, publicKey)
 }
 
-func getSupportedServices() []Value {
-	gqlSchema := introspectSauron()
+func getSupportedServices(ctx context.Context) ([]Value, error) {
+	gqlSchema, err := introspectSauron(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for _, val := range gqlSchema.Schema.Types {
 		if val.Kind == "ENUM" && val.Name == "Source" {
-			return val.EnumValues
+			return val.EnumValues, nil
 		}
 	}
-	return nil
+	return nil, nil
 }
 
-func validateInputData(input InputData) (InputData, error) {
-	services := getSupportedServices()
+func validateInputData(ctx context.Context, input InputData) (InputData, error) {
+	services, err := getSupportedServices(ctx)
+	if err != nil {
+		return nil, err
+	}
 	
 	cleanServices := make(InputData)
 	unsupportedServices := []string{}
@@ -221,57 +268,89 @@ func validateInputService(input Service) error {
 	return nil
 }
 
-func publicKeyRequest(publicKey string) bool {
+// publicKeyRequest checks that the public key belongs to a registered
+// application. Failures to reach Sauron are reported as SauronUnavailable,
+// so that they are not mistaken for an invalid key.
+func publicKeyRequest(ctx context.Context, publicKey string) error {
+	app, err := getAppByPublicKey(ctx, publicKey)
+	if err != nil {
+		return err
+	}
+	if app.GandalfID <= 0 {
+		return &GandalfError{
+			Message: "Invalid public key",
+			Code:    InvalidPublicKey,
+		}
+	}
+	return nil
+}
+
+func getAppByPublicKey(ctx context.Context, publicKey string) (*Application, error) {
 	graphqlRequest := graphqlClient.NewRequest(`
 	query GetAppByPublicKey($publicKey: String!) {
 	  getAppByPublicKey(
 		publicKey: $publicKey
 		) {
 		appName
+		iconURL
 		gandalfID
 	  }
 	}
   `)
-  
-	graphqlRequest.Var("publicKey", publicKey)
-  	client := graphqlClient.NewClient(SAURON_BASE_URL)
 
-	ctx := context.Background()
+	graphqlRequest.Var("publicKey", publicKey)
+	client := graphqlClient.NewClient(SAURON_BASE_URL)
 
 	var graphqlResponse map[string]interface{}
 
 	if err := client.Run(ctx, graphqlRequest, &graphqlResponse); err != nil {
-		log.Printf("Error making publicKey request query: %v", err)
-		return false
+		return nil, appLookupError(err)
 	}
 
 	responseData, ok := graphqlResponse["getAppByPublicKey"].(map[string]interface{})
 	if !ok {
-		log.Printf("Unexpected response structure: %v", graphqlResponse)
-		return false
+		return nil, &GandalfError{
+			Message: "Invalid public key",
+			Code:    InvalidPublicKey,
+		}
 	}
 
 	body, err := json.Marshal(responseData)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
 	var respData Application
 	err = json.Unmarshal(body, &respData)
 	if err != nil {
-		return false
+		return nil, err
+	}
+	return &respData, nil
+}
+
+// appLookupError reports an error of the getAppByPublicKey query. Sauron
+// answering with GraphQL errors means it knows no application for the key;
+// anything else, such as a network error or a 5xx response, means Sauron
+// could not be asked.
+func appLookupError(err error) error {
+	var statusErr *graphqlClient.StatusError
+	var graphqlErrs graphqlClient.GraphQLErrors
+	if errors.As(err, &graphqlErrs) && !(errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError) {
+		return &GandalfError{
+			Message: "Invalid public key",
+			Code:    InvalidPublicKey,
+		}
+	}
+	return &GandalfError{
+		Message: fmt.Sprintf("Error making publicKey request query: %v", err),
+		Code:    SauronUnavailable,
 	}
-	return respData.GandalfID > 0
 }
 
-func runValidation(publicKey string, redirectURL string, input InputData, verificationStatus bool) (InputData, error) {
+func runValidation(ctx context.Context, publicKey string, redirectURL string, input InputData, verificationStatus bool) (InputData, error) {
 	if !verificationStatus {
-		isPublicKeyValid := validatePublicKey(publicKey)
-		if !isPublicKeyValid {
-			return nil, &GandalfError{
-				Message: "Invalid public key",
-				Code:    InvalidPublicKey,
-			}
+		if err := validatePublicKey(ctx, publicKey); err != nil {
+			return nil, err
 		}
 
 		err := validateRedirectURL(redirectURL)
@@ -279,7 +358,7 @@ func runValidation(publicKey string, redirectURL string, input InputData, verifi
 			return nil, err
 		}
 
-		services, err := validateInputData(input)
+		services, err := validateInputData(ctx, input)
 		if err != nil {
 			return nil, err
 		}
@@ -309,10 +388,18 @@ func (c *Connect) encodeComponents(data, redirectUrl string, publicKey string) (
 	return fmt.Sprintf("%s?data=%s&redirectUrl=%s&publicKey=%s", baseURL, encodedServices, encodedRedirectURL, encodedPublicKey), nil
 }
 
-func servicesToJSON(services InputData) []byte {
-	servicesJSON, err := json.Marshal(services)
+func servicesToJSON(services InputData) ([]byte, error) {
+	payload, err := NewPayload(services)
 	if err != nil {
-		log.Fatalf("Error marshaling JSON: %v", err)
+		return nil, err
+	}
+
+	servicesJSON, err := EncodePayload(payload)
+	if err != nil {
+		return nil, &GandalfError{
+			Message: "Encoding Error",
+			Code:    EncodingError,
+		}
 	}
-	return servicesJSON
+	return servicesJSON, nil
 }
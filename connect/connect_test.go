@@ -5,6 +5,8 @@ import (
 )
 
 func TestGenerateURL(t *testing.T) {
+	newTestSauron(t)
+
 	tests := []struct {
 		name           string
 		config         Config
@@ -14,7 +16,7 @@ func TestGenerateURL(t *testing.T) {
 		{
 			name: "Valid parameters",
 			config: Config{
-				PublicKey:   "0x036518f1c7a10fc77f835becc0aca9916c54505f771c82d87dd5943bb01ba5ca08",
+				PublicKey:   testPublicKey,
 				RedirectURL: "https://example.com/redirect",
 				Data: InputData{
 					"uber": Service{
@@ -70,6 +72,8 @@ func TestGenerateURL(t *testing.T) {
 }
 
 func TestGenerateQRCode(t *testing.T) {
+	newTestSauron(t)
+
 	tests := []struct {
 		name           string
 		config         Config
@@ -79,7 +83,7 @@ func TestGenerateQRCode(t *testing.T) {
 		{
 			name: "Valid parameters",
 			config: Config{
-				PublicKey:   "0x036518f1c7a10fc77f835becc0aca9916c54505f771c82d87dd5943bb01ba5ca08",
+				PublicKey:   testPublicKey,
 				RedirectURL: "https://example.com/redirect",
 				Data: InputData{
 					"uber": Service{
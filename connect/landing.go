@@ -0,0 +1,379 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// landingPageTimeout bounds the Sauron requests made for a landing page
+// request, on top of the context of the request.
+const landingPageTimeout = 10 * time.Second
+
+// Theme controls the look of the landing page and email rendered for a Connect.
+type Theme struct {
+	// Title is the heading shown above the QR code.
+	Title string
+	// Message is the text shown below the heading.
+	Message string
+	// ButtonText is the label of the tap-through link.
+	ButtonText string
+
+	// The colors and font family are CSS values. They are escaped by
+	// html/template, which rejects values containing quotes.
+	BackgroundColor string
+	TextColor       string
+	AccentColor     string
+	FontFamily      string
+}
+
+// DefaultTheme is used for any Theme field left empty.
+var DefaultTheme = Theme{
+	Title:           "Connect your account",
+	Message:         "Scan the QR code with your phone, or tap the button below on your phone, to link your account.",
+	ButtonText:      "Connect",
+	BackgroundColor: "#ffffff",
+	TextColor:       "#111111",
+	AccentColor:     "#000000",
+	FontFamily:      "-apple-system, BlinkMacSystemFont, Segoe UI, Helvetica, Arial, sans-serif",
+}
+
+func (t Theme) withDefaults() Theme {
+	if t.Title == "" {
+		t.Title = DefaultTheme.Title
+	}
+	if t.Message == "" {
+		t.Message = DefaultTheme.Message
+	}
+	if t.ButtonText == "" {
+		t.ButtonText = DefaultTheme.ButtonText
+	}
+	if t.BackgroundColor == "" {
+		t.BackgroundColor = DefaultTheme.BackgroundColor
+	}
+	if t.TextColor == "" {
+		t.TextColor = DefaultTheme.TextColor
+	}
+	if t.AccentColor == "" {
+		t.AccentColor = DefaultTheme.AccentColor
+	}
+	if t.FontFamily == "" {
+		t.FontFamily = DefaultTheme.FontFamily
+	}
+	return t
+}
+
+// pageContent is the data shared by the landing page and the email.
+type pageContent struct {
+	Theme   Theme
+	AppName string
+	IconURL string
+	QRCode  htmltemplate.URL
+	URL     string
+}
+
+// landingDetails is what the content of every platform is built from: the
+// application, the validated services and the QR code.
+type landingDetails struct {
+	app          *Application
+	servicesJSON string
+	qrCode       string
+}
+
+// LandingPage is an http.Handler serving a page with the application name and
+// icon, the Connect QR code and a tap-through link for the platform detected
+// from the User-Agent of the request.
+//
+// The application details and QR code are fetched from Sauron, and the
+// Connect validated, on the first request, and reused afterwards. Failures
+// are logged and answered with a generic 502 Bad Gateway page.
+type LandingPage struct {
+	connect Connect
+	theme   Theme
+
+	fetch   singleflight.Group
+	mu      sync.Mutex
+	details *landingDetails
+	content map[PlatformType]*pageContent
+}
+
+// NewLandingPage creates a landing page handler for the Connect. Later
+// changes to conn do not affect the page.
+func NewLandingPage(conn *Connect, theme Theme) *LandingPage {
+	return &LandingPage{
+		connect: *conn,
+		theme:   theme.withDefaults(),
+		content: make(map[PlatformType]*pageContent),
+	}
+}
+
+func (p *LandingPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), landingPageTimeout)
+	defer cancel()
+
+	content, err := p.pageContent(ctx, DetectPlatform(r.UserAgent()))
+	if err != nil {
+		log.Printf("Error rendering the Connect landing page: %v", err)
+		http.Error(w, "The Connect page is unavailable, please try again later.", http.StatusBadGateway)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := landingPageTemplate.Execute(&buf, content); err != nil {
+		log.Printf("Error rendering the Connect landing page: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+// pageContent returns the content of the page for the platform. Requests to
+// Sauron are made without holding the lock, so a slow Sauron does not block
+// requests for platforms already cached.
+func (p *LandingPage) pageContent(ctx context.Context, platform PlatformType) (*pageContent, error) {
+	p.mu.Lock()
+	if content, ok := p.content[platform]; ok {
+		p.mu.Unlock()
+		return content, nil
+	}
+	details := p.details
+	p.mu.Unlock()
+
+	if details == nil {
+		var err error
+		details, err = p.fetchDetails(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := newPageContent(&p.connect, platform, p.theme, details.app, details.servicesJSON, htmltemplate.URL(details.qrCode))
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.content[platform] = content
+	return content, nil
+}
+
+// fetchDetails fetches the application, validates the Connect and renders
+// its QR code. Concurrent first requests share a single fetch, made with the
+// context of the request that started it: should that request end first,
+// the others fail with it and the next request fetches again.
+func (p *LandingPage) fetchDetails(ctx context.Context) (*landingDetails, error) {
+	details, err, _ := p.fetch.Do("details", func() (interface{}, error) {
+		app, err := getAppByPublicKey(ctx, p.connect.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		servicesJSON, err := p.connect.validatedServices(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		qrCode, err := p.connect.qrCodePNG(servicesJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		details := &landingDetails{app: app, servicesJSON: servicesJSON, qrCode: qrCodeDataURI(qrCode)}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.details = details
+		return details, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return details.(*landingDetails), nil
+}
+
+// DetectPlatform picks the Connect platform matching a User-Agent header.
+// Devices that are neither iOS nor Android get PlatformUniversal.
+func DetectPlatform(userAgent string) PlatformType {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return PlatformTypeIOS
+	case strings.Contains(ua, "android"):
+		return PlatformTypeAndroid
+	default:
+		return PlatformUniversal
+	}
+}
+
+// Email is a rendered Connect invitation with HTML and plain-text bodies.
+//
+// The HTML body shows the QR code as an inline image referenced by its
+// Content-ID, since Gmail and Outlook block images embedded as data URIs.
+// Send it as a multipart/related message with QRCode as an inline part:
+//
+//	Content-Type: image/png
+//	Content-Disposition: inline; filename="qrcode.png"
+//	Content-ID: <connect-qrcode@gandalf.network>
+type Email struct {
+	HTML   string
+	Text   string
+	QRCode InlineImage
+}
+
+// InlineImage is an image attached to an email and referenced from its HTML
+// body as cid:ContentID.
+type InlineImage struct {
+	// ContentID is the value of the Content-ID header of the part, without
+	// the angle brackets.
+	ContentID   string
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+// QRCodeContentID is the Content-ID of the QR code of a rendered Email.
+const QRCodeContentID = "connect-qrcode@gandalf.network"
+
+// RenderEmail renders the landing page content as an email. The HTML body
+// uses tables and inline styles only, and links to the universal Connect URL.
+// The link is always included next to the QR code, for email clients that do
+// not show images.
+func RenderEmail(conn *Connect, theme Theme) (*Email, error) {
+	ctx := context.Background()
+	app, err := getAppByPublicKey(ctx, conn.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	servicesJSON, err := conn.validatedServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qrCode, err := conn.qrCodePNG(servicesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := newPageContent(conn, PlatformUniversal, theme.withDefaults(), app, servicesJSON, htmltemplate.URL("cid:"+QRCodeContentID))
+	if err != nil {
+		return nil, err
+	}
+
+	var html, text bytes.Buffer
+	if err := emailHTMLTemplate.Execute(&html, content); err != nil {
+		return nil, err
+	}
+	if err := emailTextTemplate.Execute(&text, content); err != nil {
+		return nil, err
+	}
+	return &Email{
+		HTML: html.String(),
+		Text: text.String(),
+		QRCode: InlineImage{
+			ContentID:   QRCodeContentID,
+			ContentType: "image/png",
+			Filename:    "qrcode.png",
+			Data:        qrCode,
+		},
+	}, nil
+}
+
+// newPageContent builds the content for the platform from services returned
+// by validatedServices. qrCode is the source of the QR code image, such as a
+// data URI, which html/template would otherwise replace with a placeholder.
+func newPageContent(conn *Connect, platform PlatformType, theme Theme, app *Application, servicesJSON string, qrCode htmltemplate.URL) (*pageContent, error) {
+	platformConnect := *conn
+	platformConnect.Platform = platform
+
+	url, err := platformConnect.appClipURL(servicesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pageContent{
+		Theme:   theme,
+		AppName: app.AppName,
+		IconURL: app.IconURL,
+		QRCode:  qrCode,
+		URL:     url,
+	}, nil
+}
+
+var landingPageTemplate = htmltemplate.Must(htmltemplate.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Theme.Title}} · {{.AppName}}</title>
+<style>
+body { margin: 0; padding: 32px 16px; background: {{.Theme.BackgroundColor}}; color: {{.Theme.TextColor}}; font-family: {{.Theme.FontFamily}}; text-align: center; }
+main { max-width: 420px; margin: 0 auto; }
+.icon { width: 64px; height: 64px; border-radius: 16px; }
+.qrcode { width: 256px; height: 256px; }
+.button { display: inline-block; padding: 12px 32px; border-radius: 8px; background: {{.Theme.AccentColor}}; color: {{.Theme.BackgroundColor}}; text-decoration: none; font-weight: 600; }
+</style>
+</head>
+<body>
+<main>
+{{if .IconURL}}<img class="icon" src="{{.IconURL}}" alt="{{.AppName}}">{{end}}
+<h1>{{.Theme.Title}}</h1>
+<p><strong>{{.AppName}}</strong></p>
+<p>{{.Theme.Message}}</p>
+<img class="qrcode" src="{{.QRCode}}" alt="Connect QR code">
+<p><a class="button" href="{{.URL}}">{{.Theme.ButtonText}}</a></p>
+</main>
+</body>
+</html>
+`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("email").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Theme.Title}}</title>
+</head>
+<body style="margin:0;padding:0;background-color:{{.Theme.BackgroundColor}};">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color:{{.Theme.BackgroundColor}};">
+<tr>
+<td align="center" style="padding:32px 16px;font-family:{{.Theme.FontFamily}};color:{{.Theme.TextColor}};">
+<table role="presentation" width="420" cellpadding="0" cellspacing="0" border="0">
+{{if .IconURL}}<tr><td align="center" style="padding-bottom:16px;"><img src="{{.IconURL}}" width="64" height="64" alt="{{.AppName}}" style="display:block;border:0;"></td></tr>{{end}}
+<tr><td align="center" style="font-size:24px;font-weight:bold;padding-bottom:8px;">{{.Theme.Title}}</td></tr>
+<tr><td align="center" style="font-size:16px;font-weight:bold;padding-bottom:8px;">{{.AppName}}</td></tr>
+<tr><td align="center" style="font-size:16px;padding-bottom:16px;">{{.Theme.Message}}</td></tr>
+<tr><td align="center" style="padding-bottom:16px;"><img src="{{.QRCode}}" width="256" height="256" alt="Connect QR code" style="display:block;border:0;"></td></tr>
+<tr><td align="center"><a href="{{.URL}}" style="display:inline-block;padding:12px 32px;border-radius:8px;background-color:{{.Theme.AccentColor}};color:{{.Theme.BackgroundColor}};text-decoration:none;font-weight:bold;">{{.Theme.ButtonText}}</a></td></tr>
+</table>
+</td>
+</tr>
+</table>
+</body>
+</html>
+`))
+
+var emailTextTemplate = texttemplate.Must(texttemplate.New("email").Parse(`{{.Theme.Title}}
+
+{{.AppName}}
+
+{{.Theme.Message}}
+
+{{.Theme.ButtonText}}: {{.URL}}
+`))
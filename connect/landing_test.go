@@ -0,0 +1,356 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		expected  PlatformType
+	}{
+		{
+			name:      "iPhone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			expected:  PlatformTypeIOS,
+		},
+		{
+			name:      "Android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			expected:  PlatformTypeAndroid,
+		},
+		{
+			name:      "Desktop",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			expected:  PlatformUniversal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectPlatform(tt.userAgent); got != tt.expected {
+				t.Errorf("DetectPlatform() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLandingPage(t *testing.T) {
+	server := newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"netflix": Service{Traits: []string{"rating"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+
+	handler := NewLandingPage(conn, Theme{AccentColor: "#ff0066"})
+
+	for _, userAgent := range []string{"Mozilla/5.0 (Linux; Android 14)", "Mozilla/5.0 (Linux; Android 14)"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ServeHTTP() status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+
+		body := rec.Body.String()
+		for _, want := range []string{
+			"Example",
+			`src="https://example.com/icon.png"`,
+			`src="data:image/png;base64,`,
+			`href="` + ANDROID_APP_CLIP_BASE_URL,
+			"#ff0066",
+			DefaultTheme.Title,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("ServeHTTP() body is missing %q", want)
+			}
+		}
+		if strings.Contains(body, "ZgotmplZ") {
+			t.Error("ServeHTTP() body contains a filtered template value")
+		}
+	}
+
+	// One request for the application and two to validate the Connect once,
+	// for both the QR code and the URL.
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("ServeHTTP() made %d Sauron requests, want 3", got)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ServeHTTP() POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRenderEmail(t *testing.T) {
+	newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"uber": true},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+
+	email, err := RenderEmail(conn, Theme{Title: "Link your Uber account"})
+	if err != nil {
+		t.Fatalf("RenderEmail() error = %v", err)
+	}
+
+	for _, want := range []string{"Link your Uber account", "Example", `src="cid:` + QRCodeContentID + `"`, `href="` + UNIVERSAL_APP_CLIP_BASE_URL} {
+		if !strings.Contains(email.HTML, want) {
+			t.Errorf("RenderEmail() HTML is missing %q", want)
+		}
+	}
+	// Gmail and Outlook block images embedded as data URIs.
+	if strings.Contains(email.HTML, "data:") {
+		t.Error("RenderEmail() HTML embeds a data URI")
+	}
+	if email.QRCode.ContentID != QRCodeContentID || email.QRCode.ContentType != "image/png" || !bytes.HasPrefix(email.QRCode.Data, []byte("\x89PNG")) {
+		t.Errorf("RenderEmail() QR code = %s %s, %d bytes, want a PNG image", email.QRCode.ContentID, email.QRCode.ContentType, len(email.QRCode.Data))
+	}
+	if strings.Contains(email.HTML, "<style") || strings.Contains(email.HTML, "<script") {
+		t.Error("RenderEmail() HTML must only use inline styles")
+	}
+	if strings.Contains(email.HTML, "ZgotmplZ") {
+		t.Error("RenderEmail() HTML contains a filtered template value")
+	}
+
+	for _, want := range []string{"Link your Uber account", "Example", "Connect: " + UNIVERSAL_APP_CLIP_BASE_URL} {
+		if !strings.Contains(email.Text, want) {
+			t.Errorf("RenderEmail() text is missing %q", want)
+		}
+	}
+}
+
+func TestLandingPageErrors(t *testing.T) {
+	server := newTestSauron(t)
+
+	tests := []struct {
+		name      string
+		publicKey string
+		fault     *saurontest.Fault
+	}{
+		{
+			name:      "Unknown application",
+			publicKey: "0x024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e",
+		},
+		{
+			name:      "Sauron unavailable",
+			publicKey: testPublicKey,
+			fault:     &saurontest.Fault{StatusCode: http.StatusServiceUnavailable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ClearFaults()
+			if tt.fault != nil {
+				server.Inject("getAppByPublicKey", *tt.fault)
+			}
+
+			conn, err := NewConnect(Config{
+				PublicKey:   tt.publicKey,
+				RedirectURL: "https://example.com/redirect",
+				Data:        InputData{"uber": true},
+			})
+			if err != nil {
+				t.Fatalf("NewConnect() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			NewLandingPage(conn, Theme{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusBadGateway)
+			}
+			// The cause is logged, not shown to the visitor.
+			for _, detail := range []string{"public key", "code:", "503"} {
+				if strings.Contains(rec.Body.String(), detail) {
+					t.Errorf("ServeHTTP() body = %q, want no error details", rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEmailErrors(t *testing.T) {
+	server := newTestSauron(t)
+
+	tests := []struct {
+		name      string
+		publicKey string
+		field     string
+		fault     saurontest.Fault
+		wantCode  GandalfErrorCode
+	}{
+		{
+			name:      "Unknown application",
+			publicKey: "0x024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e",
+			wantCode:  InvalidPublicKey,
+		},
+		{
+			name:      "Application lookup unavailable",
+			publicKey: testPublicKey,
+			field:     "getAppByPublicKey",
+			fault:     saurontest.Fault{StatusCode: http.StatusBadGateway},
+			wantCode:  SauronUnavailable,
+		},
+		{
+			name:      "Introspection unavailable",
+			publicKey: testPublicKey,
+			field:     "__schema",
+			fault:     saurontest.Fault{StatusCode: http.StatusServiceUnavailable},
+			wantCode:  SauronUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ClearFaults()
+			if tt.field != "" {
+				server.Inject(tt.field, tt.fault)
+			}
+
+			conn, err := NewConnect(Config{
+				PublicKey:   tt.publicKey,
+				RedirectURL: "https://example.com/redirect",
+				Data:        InputData{"uber": true},
+			})
+			if err != nil {
+				t.Fatalf("NewConnect() error = %v", err)
+			}
+
+			_, err = RenderEmail(conn, Theme{})
+			var gandalfErr *GandalfError
+			if !errors.As(err, &gandalfErr) || gandalfErr.Code != tt.wantCode {
+				t.Errorf("RenderEmail() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLandingPageDoesNotBlockOnSauron(t *testing.T) {
+	server := newTestSauron(t)
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"uber": true},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+	handler := NewLandingPage(conn, Theme{})
+
+	android := httptest.NewRequest(http.MethodGet, "/", nil)
+	android.Header.Set("User-Agent", "Mozilla/5.0 (Linux; Android 14)")
+	handler.ServeHTTP(httptest.NewRecorder(), android)
+
+	// The first iOS request waits on a slow Sauron while Android is served
+	// from the cache.
+	server.Inject("__schema", saurontest.Fault{Latency: 500 * time.Millisecond})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ios := httptest.NewRequest(http.MethodGet, "/", nil)
+		ios.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X)")
+		handler.ServeHTTP(httptest.NewRecorder(), ios)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, android)
+	if elapsed := time.Since(start); rec.Code != http.StatusOK || elapsed > 250*time.Millisecond {
+		t.Errorf("cached ServeHTTP() = %d after %v while Sauron is slow, want 200 at once", rec.Code, elapsed)
+	}
+	<-done
+}
+
+func TestLandingPageSharesFirstFetch(t *testing.T) {
+	server := newTestSauron(t)
+	server.Inject("getAppByPublicKey", saurontest.Fault{Latency: 100 * time.Millisecond})
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"uber": true},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+	handler := NewLandingPage(conn, Theme{})
+
+	var wg sync.WaitGroup
+	for _, userAgent := range []string{"Mozilla/5.0 (Linux; Android 14)", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X)", "curl/8.5.0", "curl/8.5.0"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", userAgent)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("ServeHTTP() for %s status = %d", userAgent, rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("concurrent first requests made %d Sauron requests, want 3", got)
+	}
+}
+
+func TestLandingPageRequestContext(t *testing.T) {
+	server := newTestSauron(t)
+	server.Inject("getAppByPublicKey", saurontest.Fault{Latency: time.Second, Times: 1})
+
+	conn, err := NewConnect(Config{
+		PublicKey:   testPublicKey,
+		RedirectURL: "https://example.com/redirect",
+		Data:        InputData{"uber": true},
+	})
+	if err != nil {
+		t.Fatalf("NewConnect() error = %v", err)
+	}
+	handler := NewLandingPage(conn, Theme{})
+
+	// The Sauron request ends with the request that made it.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if elapsed := time.Since(start); rec.Code != http.StatusBadGateway || elapsed > 500*time.Millisecond {
+		t.Errorf("ServeHTTP() = %d after %v with a request ending in 50ms, want 502 at once", rec.Code, elapsed)
+	}
+
+	// The failed fetch is not cached.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("ServeHTTP() after a canceled request = %d, want 200", rec.Code)
+	}
+}
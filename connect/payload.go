@@ -0,0 +1,211 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PayloadVersion is the latest version of the Connect payload schema.
+const PayloadVersion = 1
+
+// Payload is the schema of the `data` parameter of a Connect URL.
+//
+// By default it is encoded in the unversioned format read by the Connect app,
+// a JSON object of the services with the keys in a fixed order:
+//
+//	{"NETFLIX":{"traits":["RATING"],"activities":["WATCH"]},"UBER":true}
+//
+// Setting Version to PayloadVersion opts in to the versioned format, which
+// wraps the services in an envelope:
+//
+//	{"version":1,"services":{"NETFLIX":{"traits":["RATING"],"activities":["WATCH"]},"UBER":true}}
+//
+// Service names are uppercase and sorted. A service without traits or
+// activities is encoded as `true`. Unknown keys of the envelope are ignored
+// when decoding so that older SDKs can read payloads carrying fields added
+// later.
+type Payload struct {
+	// Version is 0 for the unversioned format.
+	Version  int
+	Services map[string]Service
+}
+
+// NewPayload builds an unversioned Payload from the services of an
+// InputData, where each value is either `true` or a Service. Service names
+// are uppercased, and names that only differ in case are rejected.
+func NewPayload(input InputData) (*Payload, error) {
+	payload := &Payload{
+		Services: make(map[string]Service, len(input)),
+	}
+
+	for key, value := range input {
+		var service Service
+		switch v := value.(type) {
+		case bool:
+			if !v {
+				return nil, &GandalfError{
+					Message: fmt.Sprintf("Service %s must be true or a service definition", key),
+					Code:    InvalidPayload,
+				}
+			}
+		case Service:
+			service = v
+		default:
+			return nil, &GandalfError{
+				Message: fmt.Sprintf("Unsupported value type for key %s", key),
+				Code:    InvalidPayload,
+			}
+		}
+		if err := payload.add(key, service); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// add adds a service under its uppercase name, unless a service of the same
+// name in another case was already added.
+func (p *Payload) add(name string, service Service) error {
+	upper := strings.ToUpper(name)
+	if _, ok := p.Services[upper]; ok {
+		return &GandalfError{
+			Message: fmt.Sprintf("Service %s is given more than once", upper),
+			Code:    InvalidService,
+		}
+	}
+	p.Services[upper] = service
+	return nil
+}
+
+// InputData converts the payload back into the InputData accepted by Config.
+func (p *Payload) InputData() InputData {
+	input := make(InputData, len(p.Services))
+	for name, service := range p.Services {
+		if len(service.Traits) == 0 && len(service.Activities) == 0 {
+			input[name] = true
+			continue
+		}
+		input[name] = service
+	}
+	return input
+}
+
+// EncodePayload encodes the payload into its canonical JSON form, in the
+// unversioned format unless Version is set.
+func EncodePayload(p *Payload) ([]byte, error) {
+	if p.Version < 0 || p.Version > PayloadVersion {
+		return nil, &GandalfError{
+			Message: fmt.Sprintf("Unsupported payload version %d", p.Version),
+			Code:    InvalidPayload,
+		}
+	}
+
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	if p.Version > 0 {
+		fmt.Fprintf(&buf, `{"version":%d,"services":`, p.Version)
+	}
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(strings.ToUpper(name))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		service := p.Services[name]
+		if len(service.Traits) == 0 && len(service.Activities) == 0 {
+			buf.WriteString("true")
+			continue
+		}
+		value, err := json.Marshal(service)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	if p.Version > 0 {
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePayload parses a payload produced by EncodePayload, in either format.
+// Unversioned payloads, which carry the services at the top level, are
+// decoded with Version set to 0.
+func DecodePayload(data []byte) (*Payload, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, &GandalfError{
+			Message: fmt.Sprintf("Invalid payload: %v", err),
+			Code:    InvalidPayload,
+		}
+	}
+
+	payload := &Payload{}
+	services := raw
+	if version, ok := raw["version"]; ok {
+		if err := json.Unmarshal(version, &payload.Version); err != nil || payload.Version < 1 {
+			return nil, &GandalfError{
+				Message: fmt.Sprintf("Invalid payload version %s", version),
+				Code:    InvalidPayload,
+			}
+		}
+		if payload.Version > PayloadVersion {
+			return nil, &GandalfError{
+				Message: fmt.Sprintf("Unsupported payload version %d", payload.Version),
+				Code:    InvalidPayload,
+			}
+		}
+
+		services = nil
+		if err := json.Unmarshal(raw["services"], &services); err != nil {
+			return nil, &GandalfError{
+				Message: fmt.Sprintf("Invalid payload services: %v", err),
+				Code:    InvalidPayload,
+			}
+		}
+	}
+
+	payload.Services = make(map[string]Service, len(services))
+	for name, value := range services {
+		var required bool
+		if err := json.Unmarshal(value, &required); err == nil {
+			if !required {
+				return nil, &GandalfError{
+					Message: fmt.Sprintf("Service %s must be true or a service definition", name),
+					Code:    InvalidPayload,
+				}
+			}
+			if err := payload.add(name, Service{}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var service Service
+		if err := json.Unmarshal(value, &service); err != nil {
+			return nil, &GandalfError{
+				Message: fmt.Sprintf("Invalid definition for service %s: %v", name, err),
+				Code:    InvalidPayload,
+			}
+		}
+		if err := payload.add(name, service); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
@@ -0,0 +1,192 @@
+package connect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    InputData
+		expected string
+	}{
+		{
+			name: "Single service",
+			input: InputData{
+				"netflix": Service{
+					Traits:     []string{"rating"},
+					Activities: []string{"watch"},
+				},
+			},
+			expected: `{"NETFLIX":{"traits":["rating"],"activities":["watch"]}}`,
+		},
+		{
+			name: "Sorted services",
+			input: InputData{
+				"uber":    true,
+				"amazon":  Service{Activities: []string{"shop"}},
+				"netflix": Service{Traits: []string{"plan"}},
+			},
+			expected: `{"AMAZON":{"activities":["shop"]},"NETFLIX":{"traits":["plan"]},"UBER":true}`,
+		},
+		{
+			name:     "No services",
+			input:    nil,
+			expected: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := NewPayload(tt.input)
+			if err != nil {
+				t.Fatalf("NewPayload() error = %v", err)
+			}
+
+			data, err := EncodePayload(payload)
+			if err != nil {
+				t.Fatalf("EncodePayload() error = %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("EncodePayload() = %s, want %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodePayloadVersioned(t *testing.T) {
+	payload, err := NewPayload(InputData{
+		"uber":    true,
+		"netflix": Service{Traits: []string{"rating"}, Activities: []string{"watch"}},
+	})
+	if err != nil {
+		t.Fatalf("NewPayload() error = %v", err)
+	}
+	if payload.Version != 0 {
+		t.Errorf("NewPayload() version = %d, want the unversioned format", payload.Version)
+	}
+
+	payload.Version = PayloadVersion
+	data, err := EncodePayload(payload)
+	if err != nil {
+		t.Fatalf("EncodePayload() error = %v", err)
+	}
+	expected := `{"version":1,"services":{"NETFLIX":{"traits":["rating"],"activities":["watch"]},"UBER":true}}`
+	if string(data) != expected {
+		t.Errorf("EncodePayload() = %s, want %s", data, expected)
+	}
+
+	payload.Version = PayloadVersion + 1
+	if _, err := EncodePayload(payload); err == nil {
+		t.Error("EncodePayload() expected an error for an unsupported version")
+	}
+}
+
+func TestNewPayloadDuplicateService(t *testing.T) {
+	_, err := NewPayload(InputData{
+		"netflix": true,
+		"Netflix": Service{Traits: []string{"rating"}},
+	})
+	gandalfErr, ok := err.(*GandalfError)
+	if !ok || gandalfErr.Code != InvalidService {
+		t.Errorf("NewPayload() error = %v, want an InvalidService error", err)
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expected    *Payload
+		expectedErr bool
+	}{
+		{
+			name: "Versioned payload",
+			data: `{"version":1,"services":{"NETFLIX":{"traits":["rating"]},"UBER":true},"locale":"en"}`,
+			expected: &Payload{
+				Version: 1,
+				Services: map[string]Service{
+					"NETFLIX": {Traits: []string{"rating"}},
+					"UBER":    {},
+				},
+			},
+		},
+		{
+			name: "Unversioned payload",
+			data: `{"NETFLIX":{"activities":["watch"]}}`,
+			expected: &Payload{
+				Version: 0,
+				Services: map[string]Service{
+					"NETFLIX": {Activities: []string{"watch"}},
+				},
+			},
+		},
+		{
+			name:        "Unsupported version",
+			data:        `{"version":99,"services":{}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Disabled service",
+			data:        `{"version":1,"services":{"UBER":false}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Duplicate service",
+			data:        `{"NETFLIX":true,"netflix":{"traits":["rating"]}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Malformed JSON",
+			data:        `{"version":`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := DecodePayload([]byte(tt.data))
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("DecodePayload() expected an error, got %+v", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodePayload() error = %v", err)
+			}
+			if !reflect.DeepEqual(payload, tt.expected) {
+				t.Errorf("DecodePayload() = %+v, want %+v", payload, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	input := InputData{
+		"uber":    true,
+		"netflix": Service{Traits: []string{"rating"}, Activities: []string{"watch"}},
+	}
+
+	payload, err := NewPayload(input)
+	if err != nil {
+		t.Fatalf("NewPayload() error = %v", err)
+	}
+	data, err := EncodePayload(payload)
+	if err != nil {
+		t.Fatalf("EncodePayload() error = %v", err)
+	}
+	decoded, err := DecodePayload(data)
+	if err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+
+	expected := InputData{
+		"UBER":    true,
+		"NETFLIX": Service{Traits: []string{"rating"}, Activities: []string{"watch"}},
+	}
+	if got := decoded.InputData(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("InputData() = %+v, want %+v", got, expected)
+	}
+}
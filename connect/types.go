@@ -7,6 +7,8 @@ type Connect struct {
 	Platform 			PlatformType
 	VerificationStatus 	bool
 	Data 				InputData
+	// BatchConcurrency limits how many URLs GenerateBatch generates at the same time.
+	BatchConcurrency	int
 }
 
 type Config struct {
@@ -14,6 +16,9 @@ type Config struct {
 	RedirectURL string
 	Platform    PlatformType
 	Data 		InputData
+	// BatchConcurrency limits how many URLs GenerateBatch generates at the same time.
+	// Defaults to DefaultBatchConcurrency.
+	BatchConcurrency int
 }
 
 type PlatformType string
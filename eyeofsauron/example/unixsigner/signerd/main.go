@@ -0,0 +1,51 @@
+//go:build unix
+
+// Command signerd signs EyeOfSauron requests on behalf of other processes,
+// reading the private key, as hex or PEM, from the GANDALF_PRIVATE_KEY
+// environment variable and listening on a Unix socket.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/example/generated"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/example/unixsigner"
+)
+
+func main() {
+	socket := flag.String("socket", "/tmp/gandalf-signer.sock", "path of the Unix socket to listen on")
+	flag.Parse()
+
+	privateKey, err := generated.PrivateKeyFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load private key: %s", err)
+	}
+
+	os.Remove(*socket)
+	// Only the owner of the daemon may ask for signatures. The socket is
+	// created with these permissions rather than restricted afterwards, so
+	// that no other user can connect in between.
+	umask := syscall.Umask(0o177)
+	l, err := net.Listen("unix", *socket)
+	syscall.Umask(umask)
+	if err != nil {
+		log.Fatalf("failed to listen: %s", err)
+	}
+
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+		l.Close()
+	}()
+
+	log.Printf("signing requests on %s", *socket)
+	if err := unixsigner.Serve(l, generated.NewPrivateKeySigner(privateKey)); err != nil {
+		log.Fatalf("failed to serve: %s", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Package unixsigner is an example Signer that delegates request signing to a
+// daemon listening on a Unix socket, so that the private key stays out of the
+// process making requests.
+//
+// The daemon, such as the one in the signerd directory, serves a Backend with
+// Serve. The client passes a Signer to the generated client:
+//
+//	eye, err := generated.NewEyeOfSauron("", generated.WithSigner(unixsigner.New("/run/gandalf/signer.sock")))
+//
+// Every request is a connection carrying one JSON object, {"body":"<base64>"},
+// answered by {"signature":"<base64>"} or {"error":"<message>"}. Clients
+// that take longer than 10 seconds to send the request are disconnected.
+package unixsigner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// requestTimeout is how long Serve waits for a client to send its request,
+// and then to read the response, before closing the connection. Without it,
+// idle clients would hold a connection and its goroutine forever.
+const requestTimeout = 10 * time.Second
+
+// Backend signs messages on behalf of the daemon, such as a
+// generated.PrivateKeySigner.
+type Backend interface {
+	Sign(ctx context.Context, body []byte) (string, error)
+}
+
+type signRequest struct {
+	Body []byte `json:"body"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Signer asks the daemon listening on a Unix socket to sign requests.
+type Signer struct {
+	path   string
+	dialer net.Dialer
+}
+
+// New returns a Signer for the daemon listening on the socket at path.
+func New(path string) *Signer {
+	return &Signer{path: path}
+}
+
+func (s *Signer) Sign(ctx context.Context, body []byte) (string, error) {
+	conn, err := s.dialer.DialContext(ctx, "unix", s.path)
+	if err != nil {
+		return "", fmt.Errorf("could not reach signer: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the exchange below when ctx is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(signRequest{Body: body}); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("could not send to signer: %w", err)
+	}
+	var res signResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("could not read from signer: %w", err)
+	}
+	if res.Error != "" {
+		return "", fmt.Errorf("signer: %s", res.Error)
+	}
+	if res.Signature == "" {
+		return "", fmt.Errorf("signer returned no signature")
+	}
+	return res.Signature, nil
+}
+
+// Serve answers the signing requests accepted on l with backend until l is
+// closed, when it returns nil.
+func Serve(l net.Listener, backend Backend) error {
+	return serve(l, backend, requestTimeout)
+}
+
+func serve(l net.Listener, backend Backend, timeout time.Duration) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, backend, timeout)
+	}
+}
+
+func serveConn(conn net.Conn, backend Backend, timeout time.Duration) {
+	defer conn.Close()
+
+	var req signRequest
+	var res signResponse
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		res.Error = fmt.Sprintf("invalid request: %v", err)
+	} else if res.Signature, err = backend.Sign(context.Background(), req.Body); err != nil {
+		res.Error = err.Error()
+	}
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+	json.NewEncoder(conn).Encode(res)
+}
@@ -0,0 +1,126 @@
+package unixsigner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type backendFunc func(ctx context.Context, body []byte) (string, error)
+
+func (f backendFunc) Sign(ctx context.Context, body []byte) (string, error) {
+	return f(ctx, body)
+}
+
+func listen(t *testing.T, backend Backend) string {
+	t.Helper()
+	return listenWithTimeout(t, backend, requestTimeout)
+}
+
+func listenWithTimeout(t *testing.T, backend Backend, timeout time.Duration) string {
+	t.Helper()
+
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir
+	// may exceed.
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- serve(l, backend, timeout) }()
+	t.Cleanup(func() {
+		l.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+	return path
+}
+
+func TestSigner(t *testing.T) {
+	path := listen(t, backendFunc(func(ctx context.Context, body []byte) (string, error) {
+		if string(body) == "fail" {
+			return "", errors.New("key unavailable")
+		}
+		return "signed:" + string(body), nil
+	}))
+	signer := New(path)
+
+	signature, err := signer.Sign(context.Background(), []byte(`{"query":"query q { q }"}`))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if expected := `signed:{"query":"query q { q }"}`; signature != expected {
+		t.Errorf("Sign() = %q, want %q", signature, expected)
+	}
+
+	if _, err := signer.Sign(context.Background(), []byte("fail")); err == nil || !strings.Contains(err.Error(), "key unavailable") {
+		t.Errorf("Sign() error = %v, want the error of the backend", err)
+	}
+}
+
+func TestSignerUnreachable(t *testing.T) {
+	signer := New(filepath.Join(os.TempDir(), "missing-signer.sock"))
+	if _, err := signer.Sign(context.Background(), []byte("body")); err == nil {
+		t.Error("Sign() expected an error when the daemon is not running")
+	}
+}
+
+func TestSignerCanceled(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	path := listen(t, backendFunc(func(ctx context.Context, body []byte) (string, error) {
+		<-block
+		return "", nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := New(path).Sign(ctx, []byte("body")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Sign() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServeIdleClient(t *testing.T) {
+	path := listenWithTimeout(t, backendFunc(func(ctx context.Context, body []byte) (string, error) {
+		return "signed", nil
+	}), 50*time.Millisecond)
+
+	// A client that never sends its request is answered with an error and
+	// disconnected once the deadline passes.
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	start := time.Now()
+	var res signResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		t.Fatalf("reading the response error = %v", err)
+	}
+	if !strings.Contains(res.Error, "invalid request") {
+		t.Errorf("response = %+v, want an invalid request error", res)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read after the response error = %v, want %v", err, io.EOF)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("idle connection closed after %v, want about 50ms", elapsed)
+	}
+}
@@ -0,0 +1,285 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// CSVWriter writes activities as CSV rows. The columns are the ID of the
+// activity followed by the fields of its metadata, named after the path of
+// their JSON tags, such as metadata.title, so every source has its own layout
+// and a CSVWriter accepts activities of a single metadata type.
+//
+// Nested objects are flattened into columns named parent.child. A list of
+// objects, such as Instacart items or UberEats customizations, is flattened
+// into child rows: an activity is written once per item, repeating the
+// columns of the activity. Only the first list of objects of a struct is
+// expanded, so that sibling lists do not multiply the rows; the others are
+// written to a single cell as JSON. Lists of identifiers are written to a
+// single cell as TYPE:value pairs separated by ';', as are lists of scalars.
+type CSVWriter struct {
+	w      *csv.Writer
+	layout *csvLayout
+}
+
+// NewCSVWriter creates a CSVWriter writing to w. The header is written with
+// the first activity.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) Write(id string, metadata interface{}) error {
+	v := reflect.ValueOf(metadata)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fmt.Errorf("activity %s has no metadata", id)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("activity %s has unsupported metadata %T", id, metadata)
+	}
+
+	if c.layout == nil {
+		c.layout = newCSVLayout(v.Type(), "metadata.")
+		if err := c.w.Write(append([]string{"id"}, c.layout.header()...)); err != nil {
+			return err
+		}
+	} else if c.layout.typ != v.Type() {
+		return fmt.Errorf("activity %s has metadata %s, but the export holds %s; export each source separately",
+			id, v.Type().Name(), c.layout.typ.Name())
+	}
+
+	rows, err := c.layout.rows(v)
+	if err != nil {
+		return fmt.Errorf("could not export activity %s: %w", id, err)
+	}
+	for _, row := range rows {
+		if err := c.w.Write(append([]string{id}, row...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvLayout maps a struct type onto CSV columns.
+type csvLayout struct {
+	typ     reflect.Type
+	columns []csvColumn
+	child   *csvChild
+}
+
+// csvColumn is a single cell, read from the field at index.
+type csvColumn struct {
+	name  string
+	index []int
+	// identifiers is set for lists of identifiers, joined into one cell.
+	identifiers bool
+}
+
+// csvChild is a list of objects flattened into child rows.
+type csvChild struct {
+	index  []int
+	layout *csvLayout
+}
+
+func newCSVLayout(t reflect.Type, prefix string) *csvLayout {
+	l := &csvLayout{typ: t}
+	l.addFields(t, prefix, nil)
+	return l
+}
+
+func (l *csvLayout) addFields(t reflect.Type, prefix string, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		// genqlient embeds fragments with a "-" tag and encodes them itself.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !isScalar(field.Type) {
+			l.addFields(field.Type, prefix, fieldIndex)
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "" || name == "__typename" {
+			continue
+		}
+		name = prefix + name
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		switch {
+		case isScalar(fieldType):
+			l.columns = append(l.columns, csvColumn{name: name, index: fieldIndex})
+		case fieldType.Kind() == reflect.Struct:
+			l.addFields(fieldType, name+".", fieldIndex)
+		case fieldType.Kind() == reflect.Slice && isIdentifier(fieldType.Elem()):
+			l.columns = append(l.columns, csvColumn{name: name, index: fieldIndex, identifiers: true})
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct && !isScalar(fieldType.Elem()) && l.child == nil:
+			l.child = &csvChild{
+				index:  fieldIndex,
+				layout: newCSVLayout(fieldType.Elem(), name+"."),
+			}
+		default:
+			// Lists of scalars, interfaces and maps, and the lists of objects
+			// after the first, are written to one cell.
+			l.columns = append(l.columns, csvColumn{name: name, index: fieldIndex})
+		}
+	}
+}
+
+// isIdentifier reports whether t is an Identifier of the schema, with a
+// value and an identifierType.
+func isIdentifier(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	var value, identifierType bool
+	for i := 0; i < t.NumField(); i++ {
+		switch jsonName(t.Field(i)) {
+		case "value":
+			value = true
+		case "identifierType":
+			identifierType = true
+		}
+	}
+	return value && identifierType
+}
+
+func (l *csvLayout) header() []string {
+	var header []string
+	for _, column := range l.columns {
+		header = append(header, column.name)
+	}
+	if l.child != nil {
+		header = append(header, l.child.layout.header()...)
+	}
+	return header
+}
+
+func (l *csvLayout) width() int {
+	width := len(l.columns)
+	if l.child != nil {
+		width += l.child.layout.width()
+	}
+	return width
+}
+
+// rows returns the rows of v: one per row of its child list, or a single row
+// if it has none.
+func (l *csvLayout) rows(v reflect.Value) ([][]string, error) {
+	row := make([]string, 0, l.width())
+	for _, column := range l.columns {
+		// Fields behind a nil pointer are left empty.
+		field, err := v.FieldByIndexErr(column.index)
+		if err != nil {
+			row = append(row, "")
+			continue
+		}
+		cell, err := column.format(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", column.name, err)
+		}
+		row = append(row, cell)
+	}
+
+	if l.child == nil {
+		return [][]string{row}, nil
+	}
+
+	list, _ := v.FieldByIndexErr(l.child.index)
+	for list.Kind() == reflect.Pointer && !list.IsNil() {
+		list = list.Elem()
+	}
+
+	var rows [][]string
+	if list.Kind() == reflect.Slice {
+		for i := 0; i < list.Len(); i++ {
+			item := list.Index(i)
+			for item.Kind() == reflect.Pointer && !item.IsNil() {
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct {
+				continue
+			}
+			itemRows, err := l.child.layout.rows(item)
+			if err != nil {
+				return nil, err
+			}
+			for _, itemRow := range itemRows {
+				rows = append(rows, append(append([]string(nil), row...), itemRow...))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		rows = [][]string{append(row, make([]string, l.child.layout.width())...)}
+	}
+	return rows, nil
+}
+
+func (c csvColumn) format(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && (c.identifiers || isScalar(v.Type().Elem())) {
+		cells := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			var cell string
+			var err error
+			if c.identifiers {
+				cell, err = formatIdentifier(v.Index(i))
+			} else {
+				cell, err = formatScalar(v.Index(i))
+			}
+			if err != nil {
+				return "", err
+			}
+			cells = append(cells, cell)
+		}
+		return strings.Join(cells, ";"), nil
+	}
+	if isScalar(v.Type()) {
+		return formatScalar(v)
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func formatIdentifier(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	var value, identifierType string
+	for i := 0; i < v.NumField(); i++ {
+		var err error
+		switch jsonName(v.Type().Field(i)) {
+		case "value":
+			value, err = formatScalar(v.Field(i))
+		case "identifierType":
+			identifierType, err = formatScalar(v.Field(i))
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return identifierType + ":" + value, nil
+}
@@ -0,0 +1,114 @@
+// Package export writes activities returned by the generated EyeOfSauron
+// client to CSV or newline-delimited JSON.
+//
+// The writers work on the metadata structs generated by genqlient, using their
+// JSON tags as column and field names, so they follow the schema the client
+// was generated from. The generated client streams every page of activities
+// into a Writer with ExportActivities:
+//
+//	w := export.NewCSVWriter(file)
+//	err := eye.ExportActivities(ctx, w, dataKey, generated.SourceInstacart)
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Writer writes the activities of a single source.
+type Writer interface {
+	// Write writes the activity with the given ID and metadata, such as a
+	// *generated.NetflixActivityMetadata.
+	Write(id string, metadata interface{}) error
+	// Flush writes any buffered data to the underlying io.Writer.
+	Flush() error
+}
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Typename returns the GraphQL type of the metadata. The metadata structs
+// generated for fragments are named after their type; the variants returned
+// by queries report it with GetTypename.
+func Typename(metadata interface{}) string {
+	if typed, ok := metadata.(interface{ GetTypename() string }); ok {
+		if typename := typed.GetTypename(); typename != "" {
+			return typename
+		}
+	}
+	t := reflect.TypeOf(metadata)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+// isScalar reports whether values of t are written to a single column.
+// Types with their own JSON encoding, such as dates, are scalars.
+func isScalar(t reflect.Type) bool {
+	if t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Interface, reflect.Map:
+		return false
+	case reflect.Pointer:
+		return isScalar(t.Elem())
+	default:
+		return true
+	}
+}
+
+// jsonName returns the JSON name of a struct field, or "" if it is not encoded.
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+// formatScalar formats a scalar value as a CSV cell. Values with a JSON
+// encoding that is a string, such as dates, are written without quotes.
+func formatScalar(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if !v.Type().Implements(jsonMarshaler) {
+			return v.String(), nil
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if !v.Type().Implements(jsonMarshaler) {
+			return fmt.Sprint(v.Interface()), nil
+		}
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	var s string
+	if json.Unmarshal(encoded, &s) == nil {
+		return s, nil
+	}
+	return string(encoded), nil
+}
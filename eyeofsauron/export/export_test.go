@@ -0,0 +1,229 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testIdentifier struct {
+	Value          string `json:"value"`
+	IdentifierType string `json:"identifierType"`
+}
+
+type testCustomization struct {
+	Customization string `json:"customization"`
+	Value         string `json:"value"`
+}
+
+type testItem struct {
+	Name           string              `json:"name"`
+	Price          string              `json:"price"`
+	Customizations []testCustomization `json:"customizations"`
+}
+
+type UberEatsActivityMetadata struct {
+	Subject    []testIdentifier `json:"subject"`
+	Date       time.Time        `json:"date"`
+	Restaurant string           `json:"restaurant"`
+	TotalPrice float64          `json:"totalPrice"`
+	Items      []testItem       `json:"items"`
+}
+
+type NetflixActivityMetadata struct {
+	Title   string           `json:"title"`
+	Subject []testIdentifier `json:"subject"`
+}
+
+// testNetflixVariant mimics the variants genqlient generates for queries,
+// which embed the fragment and report the GraphQL type.
+type testNetflixVariant struct {
+	Typename                string `json:"__typename"`
+	NetflixActivityMetadata `json:"-"`
+}
+
+func (v *testNetflixVariant) GetTypename() string { return v.Typename }
+
+type testBooking struct {
+	ID    string `json:"id"`
+	Hotel string `json:"hotel"`
+}
+
+type testTraveller struct {
+	Name string `json:"name"`
+}
+
+// BookingActivityMetadata has a field named id and two lists of objects.
+type BookingActivityMetadata struct {
+	ID         string          `json:"id"`
+	Bookings   []testBooking   `json:"bookings"`
+	Travellers []testTraveller `json:"travellers"`
+}
+
+func newBookingActivity() *BookingActivityMetadata {
+	return &BookingActivityMetadata{
+		ID: "B-1",
+		Bookings: []testBooking{
+			{ID: "R-1", Hotel: "Ritz"},
+			{ID: "R-2", Hotel: "Savoy"},
+		},
+		Travellers: []testTraveller{{Name: "Ann"}, {Name: "Bob"}, {Name: "Cy"}},
+	}
+}
+
+func newUberEatsActivity() *UberEatsActivityMetadata {
+	return &UberEatsActivityMetadata{
+		Subject:    []testIdentifier{{Value: "123", IdentifierType: "UBEREATS"}},
+		Date:       time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Restaurant: "Pizza, Pasta & Co",
+		TotalPrice: 23.5,
+		Items: []testItem{
+			{
+				Name:  "Pizza",
+				Price: "$12.00",
+				Customizations: []testCustomization{
+					{Customization: "Size", Value: "Large"},
+					{Customization: "Crust", Value: "Thin"},
+				},
+			},
+			{Name: "Soda", Price: "$2.50"},
+		},
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+
+	if err := w.Write("a1", newUberEatsActivity()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write("a2", &UberEatsActivityMetadata{Restaurant: "Empty"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"id,metadata.subject,metadata.date,metadata.restaurant,metadata.totalPrice,metadata.items.name,metadata.items.price,metadata.items.customizations.customization,metadata.items.customizations.value",
+		`a1,UBEREATS:123,2024-01-02T00:00:00Z,"Pizza, Pasta & Co",23.5,Pizza,$12.00,Size,Large`,
+		`a1,UBEREATS:123,2024-01-02T00:00:00Z,"Pizza, Pasta & Co",23.5,Pizza,$12.00,Crust,Thin`,
+		`a1,UBEREATS:123,2024-01-02T00:00:00Z,"Pizza, Pasta & Co",23.5,Soda,$2.50,,`,
+		`a2,,0001-01-01T00:00:00Z,Empty,0,,,,`,
+	}, "\n") + "\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, expected)
+	}
+
+	if err := w.Write("a3", &NetflixActivityMetadata{Title: "Film"}); err == nil {
+		t.Error("Write() expected an error for metadata of another source")
+	}
+	if err := w.Write("a4", nil); err == nil {
+		t.Error("Write() expected an error for missing metadata")
+	}
+}
+
+func TestCSVWriterEmbeddedFragment(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+
+	variant := &testNetflixVariant{
+		Typename: "NetflixActivityMetadata",
+		NetflixActivityMetadata: NetflixActivityMetadata{
+			Title: "Film",
+			Subject: []testIdentifier{
+				{Value: "tt1", IdentifierType: "IMDB"},
+				{Value: "42", IdentifierType: "TVDB"},
+			},
+		},
+	}
+	if err := w.Write("n1", variant); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	w.Flush()
+
+	expected := "id,metadata.title,metadata.subject\nn1,Film,IMDB:tt1;TVDB:42\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+
+	if err := w.Write("n1", &NetflixActivityMetadata{Title: "Film"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write("a1", newUberEatsActivity()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSON has %d lines, want 2", len(lines))
+	}
+	if expected := `{"id":"n1","metadata":{"__typename":"NetflixActivityMetadata","title":"Film","subject":null}}`; lines[0] != expected {
+		t.Errorf("line 1 = %s, want %s", lines[0], expected)
+	}
+
+	var activity struct {
+		ID       string `json:"id"`
+		Metadata struct {
+			Typename string     `json:"__typename"`
+			Items    []testItem `json:"items"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &activity); err != nil {
+		t.Fatalf("line 2 is not valid JSON: %v", err)
+	}
+	if activity.ID != "a1" || activity.Metadata.Typename != "UberEatsActivityMetadata" || len(activity.Metadata.Items) != 2 {
+		t.Errorf("line 2 = %+v", activity)
+	}
+}
+
+func TestCSVWriterSiblingLists(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+
+	if err := w.Write("b1", newBookingActivity()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	w.Flush()
+
+	// Only bookings become child rows: expanding the travellers too would
+	// write every combination of booking and traveller.
+	travellers := `"[{""name"":""Ann""},{""name"":""Bob""},{""name"":""Cy""}]"`
+	expected := strings.Join([]string{
+		"id,metadata.id,metadata.travellers,metadata.bookings.id,metadata.bookings.hotel",
+		"b1,B-1," + travellers + ",R-1,Ritz",
+		"b1,B-1," + travellers + ",R-2,Savoy",
+	}, "\n") + "\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestNDJSONWriterMetadataID(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+
+	if err := w.Write("b1", newBookingActivity()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	w.Flush()
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	expected := `{"id":"b1","metadata":{"__typename":"BookingActivityMetadata","id":"B-1",` +
+		`"bookings":[{"id":"R-1","hotel":"Ritz"},{"id":"R-2","hotel":"Savoy"}],` +
+		`"travellers":[{"name":"Ann"},{"name":"Bob"},{"name":"Cy"}]}}`
+	if line != expected {
+		t.Errorf("line = %s, want %s", line, expected)
+	}
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NDJSONWriter writes one JSON object per activity and line, with the ID of
+// the activity and its metadata, as in the responses of Sauron:
+//
+//	{"id":"...","metadata":{"__typename":"NetflixActivityMetadata","title":"..."}}
+//
+// Keeping the metadata in its own object means that a metadata field named
+// id cannot collide with the ID of the activity.
+type NDJSONWriter struct {
+	w *bufio.Writer
+}
+
+// NewNDJSONWriter creates an NDJSONWriter writing to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{w: bufio.NewWriter(w)}
+}
+
+func (n *NDJSONWriter) Write(id string, metadata interface{}) error {
+	if metadata == nil {
+		return fmt.Errorf("activity %s has no metadata", id)
+	}
+
+	fields, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("could not encode activity %s: %w", id, err)
+	}
+	fields = bytes.TrimSpace(fields)
+	if len(fields) < 2 || fields[0] != '{' {
+		return fmt.Errorf("could not encode activity %s: metadata is not an object", id)
+	}
+
+	var existing map[string]json.RawMessage
+	if err := json.Unmarshal(fields, &existing); err != nil {
+		return fmt.Errorf("could not encode activity %s: %w", id, err)
+	}
+
+	// The __typename comes first, followed by the metadata fields in their
+	// encoded order. Metadata that encodes its own __typename keeps it.
+	line := []byte(`{"id":`)
+	encodedID, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	line = append(append(line, encodedID...), `,"metadata":{`...)
+	body := bytes.TrimSpace(fields[1 : len(fields)-1])
+	if _, ok := existing["__typename"]; !ok {
+		typename, err := json.Marshal(Typename(metadata))
+		if err != nil {
+			return err
+		}
+		line = append(append(line, `"__typename":`...), typename...)
+		if len(body) > 0 {
+			line = append(line, ',')
+		}
+	}
+	line = append(line, body...)
+	line = append(line, '}', '}', '\n')
+
+	_, err = n.w.Write(line)
+	return err
+}
+
+func (n *NDJSONWriter) Flush() error {
+	return n.w.Flush()
+}
@@ -38,6 +38,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -47,6 +48,7 @@ type Client struct {
 	endpoint         string
 	httpClient       *http.Client
 	useMultipartForm bool
+	userAgent        string
 
 	// closeReq will close the request body immediately allowing for reuse of client
 	closeReq bool
@@ -110,9 +112,6 @@ func (c *Client) runWithJSON(ctx context.Context, req *Request, resp interface{}
 	}
 	c.logf(">> variables: %v", req.vars)
 	c.logf(">> query: %s", req.q)
-	gr := &graphResponse{
-		Data: resp,
-	}
 	r, err := http.NewRequest(http.MethodPost, c.endpoint, &requestBody)
 	if err != nil {
 		return err
@@ -120,6 +119,9 @@ func (c *Client) runWithJSON(ctx context.Context, req *Request, resp interface{}
 	r.Close = c.closeReq
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
 	r.Header.Set("Accept", "application/json; charset=utf-8")
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
 	for key, values := range req.Header {
 		for _, value := range values {
 			r.Header.Add(key, value)
@@ -137,17 +139,7 @@ func (c *Client) runWithJSON(ctx context.Context, req *Request, resp interface{}
 		return errors.Wrap(err, "reading body")
 	}
 	c.logf("<< %s", buf.String())
-	if err := json.NewDecoder(&buf).Decode(&gr); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
-		}
-		return errors.Wrap(err, "decoding response")
-	}
-	if len(gr.Errors) > 0 {
-		// return first error
-		return gr.Errors[0]
-	}
-	return nil
+	return decodeResponse(res, &buf, resp)
 }
 
 func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp interface{}) error {
@@ -181,9 +173,6 @@ func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp inter
 	c.logf(">> variables: %s", variablesBuf.String())
 	c.logf(">> files: %d", len(req.files))
 	c.logf(">> query: %s", req.q)
-	gr := &graphResponse{
-		Data: resp,
-	}
 	r, err := http.NewRequest(http.MethodPost, c.endpoint, &requestBody)
 	if err != nil {
 		return err
@@ -191,6 +180,9 @@ func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp inter
 	r.Close = c.closeReq
 	r.Header.Set("Content-Type", writer.FormDataContentType())
 	r.Header.Set("Accept", "application/json; charset=utf-8")
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
 	for key, values := range req.Header {
 		for _, value := range values {
 			r.Header.Add(key, value)
@@ -208,17 +200,7 @@ func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp inter
 		return errors.Wrap(err, "reading body")
 	}
 	c.logf("<< %s", buf.String())
-	if err := json.NewDecoder(&buf).Decode(&gr); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
-		}
-		return errors.Wrap(err, "decoding response")
-	}
-	if len(gr.Errors) > 0 {
-		// return first error
-		return gr.Errors[0]
-	}
-	return nil
+	return decodeResponse(res, &buf, resp)
 }
 
 // WithHTTPClient specifies the underlying http.Client to use when
@@ -238,6 +220,13 @@ func UseMultipartForm() ClientOption {
 	}
 }
 
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(client *Client) {
+		client.userAgent = userAgent
+	}
+}
+
 //ImmediatelyCloseReqBody will close the req body immediately after each request body is ready
 func ImmediatelyCloseReqBody() ClientOption {
 	return func(client *Client) {
@@ -245,21 +234,137 @@ func ImmediatelyCloseReqBody() ClientOption {
 	}
 }
 
+// decodeResponse decodes the data of a GraphQL response into resp and returns
+// its errors, if any.
+func decodeResponse(res *http.Response, body io.Reader, resp interface{}) error {
+	var gr graphResponse
+	if err := json.NewDecoder(body).Decode(&gr); err != nil {
+		if res.StatusCode != http.StatusOK {
+			return newStatusError(res, nil)
+		}
+		return errors.Wrap(err, "decoding response")
+	}
+
+	// Failed responses are errors whatever their body, even {"data":null}.
+	if res.StatusCode >= http.StatusBadRequest {
+		return newStatusError(res, gr.Errors)
+	}
+
+	hasData := len(gr.Data) > 0 && !bytes.Equal(gr.Data, []byte("null"))
+	if hasData {
+		if err := json.Unmarshal(gr.Data, resp); err != nil {
+			if res.StatusCode != http.StatusOK {
+				return newStatusError(res, gr.Errors)
+			}
+			return errors.Wrap(err, "decoding response")
+		}
+	}
+
+	if len(gr.Errors) > 0 {
+		if res.StatusCode != http.StatusOK {
+			return newStatusError(res, gr.Errors)
+		}
+		if hasData {
+			return &PartialDataError{Errors: gr.Errors}
+		}
+		return gr.Errors
+	}
+	return nil
+}
+
+// newStatusError returns the StatusError of res, wrapping errs if there are
+// any, so that an empty list never reads as an error.
+func newStatusError(res *http.Response, errs GraphQLErrors) *StatusError {
+	statusErr := &StatusError{StatusCode: res.StatusCode, Header: res.Header}
+	if len(errs) > 0 {
+		statusErr.Err = errs
+	}
+	return statusErr
+}
+
 // ClientOption are functions that are passed into NewClient to
 // modify the behaviour of the Client.
 type ClientOption func(*Client)
 
-type graphErr struct {
-	Message string
+// StatusError is returned when the server responds with a status code of
+// 400 or more, whatever the body, or with another status code other than 200
+// and either no GraphQL response or GraphQL errors.
+type StatusError struct {
+	StatusCode int
+	// Header holds the response headers, such as Retry-After.
+	Header http.Header
+	// Err holds the GraphQLErrors of the response, if any.
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("graphql: server returned a non-200 status code: %v", e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
 }
 
-func (e graphErr) Error() string {
+// GraphQLError is a single entry of the errors of a GraphQL response.
+type GraphQLError struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the response field the error relates to. Its elements are
+	// field names (string) and list indexes (float64).
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Location is a position in the GraphQL query.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Code returns the `code` extension of the error, or an empty string.
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+func (e GraphQLError) Error() string {
 	return "graphql: " + e.Message
 }
 
+// GraphQLErrors holds every error of a GraphQL response, in order. It is
+// returned by Run when the response has errors and can be retrieved with
+// errors.As.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// PartialDataError is returned by Run when the response has both data and
+// errors, for example when some fields could not be resolved. The data has
+// been decoded into the response; the fields that failed are left empty.
+type PartialDataError struct {
+	Errors GraphQLErrors
+}
+
+func (e *PartialDataError) Error() string {
+	return e.Errors.Error()
+}
+
+func (e *PartialDataError) Unwrap() error {
+	return e.Errors
+}
+
 type graphResponse struct {
-	Data   interface{}
-	Errors []graphErr
+	Data   json.RawMessage
+	Errors GraphQLErrors
 }
 
 // Request is a GraphQL request.
@@ -0,0 +1,155 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRunGraphQLErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+	}{
+		{name: "OK", statusCode: http.StatusOK},
+		{name: "Bad request", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(`{"errors":[
+					{"message":"not found","locations":[{"line":2,"column":3}],"path":["lookupActivity",0],"extensions":{"code":"NOT_FOUND"}},
+					{"message":"forbidden"}
+				]}`))
+			}))
+			defer server.Close()
+
+			var resp struct{}
+			err := NewClient(server.URL).Run(context.Background(), NewRequest("query { lookupActivity }"), &resp)
+
+			var gqlErrs GraphQLErrors
+			if !errors.As(err, &gqlErrs) {
+				t.Fatalf("Run() error = %v, want GraphQLErrors", err)
+			}
+			want := GraphQLErrors{
+				{
+					Message:    "not found",
+					Locations:  []Location{{Line: 2, Column: 3}},
+					Path:       []interface{}{"lookupActivity", float64(0)},
+					Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+				},
+				{Message: "forbidden"},
+			}
+			if !reflect.DeepEqual(gqlErrs, want) {
+				t.Errorf("Run() errors = %#v, want %#v", gqlErrs, want)
+			}
+			if got := gqlErrs[0].Code(); got != "NOT_FOUND" {
+				t.Errorf("Code() = %q, want %q", got, "NOT_FOUND")
+			}
+			if got := err.Error(); got != "graphql: not found; forbidden" {
+				t.Errorf("Error() = %q", got)
+			}
+
+			var statusErr *StatusError
+			if isStatusErr := errors.As(err, &statusErr); isStatusErr != (tt.statusCode != http.StatusOK) {
+				t.Errorf("Run() error is StatusError = %v, want %v", isStatusErr, tt.statusCode != http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestRunPartialData(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantPartial bool
+		wantName    string
+	}{
+		{
+			name:        "Data and errors",
+			body:        `{"data":{"name":"partial"},"errors":[{"message":"unresolvable"}]}`,
+			wantPartial: true,
+			wantName:    "partial",
+		},
+		{
+			name: "Null data and errors",
+			body: `{"data":null,"errors":[{"message":"unresolvable"}]}`,
+		},
+		{
+			name: "Errors only",
+			body: `{"errors":[{"message":"unresolvable"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var resp struct {
+				Name string `json:"name"`
+			}
+			err := NewClient(server.URL).Run(context.Background(), NewRequest("query { name }"), &resp)
+
+			var partialErr *PartialDataError
+			if isPartial := errors.As(err, &partialErr); isPartial != tt.wantPartial {
+				t.Fatalf("Run() error = %v, want PartialDataError = %v", err, tt.wantPartial)
+			}
+			var gqlErrs GraphQLErrors
+			if !errors.As(err, &gqlErrs) {
+				t.Errorf("Run() error = %v, want GraphQLErrors", err)
+			}
+			if resp.Name != tt.wantName {
+				t.Errorf("Run() decoded name = %q, want %q", resp.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestRunStatusErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErrs   bool
+	}{
+		{name: "Null data", statusCode: http.StatusServiceUnavailable, body: `{"data":null}`},
+		{name: "Data without errors", statusCode: http.StatusInternalServerError, body: `{"data":{"name":"stale"}}`},
+		{name: "Empty errors", statusCode: http.StatusBadGateway, body: `{"data":null,"errors":[]}`},
+		{name: "Plain text", statusCode: http.StatusTooManyRequests, body: `Too Many Requests`},
+		{name: "Errors", statusCode: http.StatusUnauthorized, body: `{"errors":[{"message":"unauthorized"}]}`, wantErrs: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var resp struct {
+				Name string `json:"name"`
+			}
+			err := NewClient(server.URL).Run(context.Background(), NewRequest("query { name }"), &resp)
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.statusCode {
+				t.Fatalf("Run() error = %v, want StatusError %d", err, tt.statusCode)
+			}
+			if hasErrs := statusErr.Err != nil; hasErrs != tt.wantErrs {
+				t.Errorf("StatusError.Err = %v, want GraphQL errors = %v", statusErr.Err, tt.wantErrs)
+			}
+			if !tt.wantErrs && err.Error() == "graphql: " {
+				t.Errorf("Error() = %q, want the status code", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package otelgraphql traces GraphQL requests with OpenTelemetry. Programs
+// that do not import it leave OpenTelemetry out of their builds.
+//
+// A Tracer runs every operation in a span named after it, such as
+// getActivity, and propagates the trace context in the request headers:
+//
+//	tracer := otelgraphql.NewTracer(tracerProvider)
+//	err := tracer.Run(ctx, client, req, &resp)
+//
+// The generated EyeOfSauron client uses a Tracer when it is generated with
+// the --tracing flag and created with WithTracerProvider.
+package otelgraphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans.
+const ScopeName = "github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql/otelgraphql"
+
+// Attributes set on the spans.
+const (
+	OperationNameKey = attribute.Key("graphql.operation.name")
+	OperationTypeKey = attribute.Key("graphql.operation.type")
+	ErrorCountKey    = attribute.Key("graphql.error.count")
+	SourceKey        = attribute.Key("gandalf.source")
+	PageKey          = attribute.Key("gandalf.page")
+	LimitKey         = attribute.Key("gandalf.limit")
+	StatusCodeKey    = attribute.Key("http.response.status_code")
+	// AttemptKey and RetryWaitKey are set on retry events.
+	AttemptKey   = attribute.Key("gandalf.attempt")
+	RetryWaitKey = attribute.Key("gandalf.retry.wait")
+)
+
+// Tracer runs GraphQL requests in spans.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures a Tracer created by NewTracer.
+type Option func(*Tracer)
+
+// WithPropagator propagates the trace context with propagator instead of the
+// W3C Trace Context and Baggage headers, for example with
+// otel.GetTextMapPropagator().
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *Tracer) {
+		t.propagator = propagator
+	}
+}
+
+// NewTracer returns a Tracer creating spans with tracerProvider, or with the
+// global tracer provider if it is nil.
+func NewTracer(tracerProvider trace.TracerProvider, opts ...Option) *Tracer {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	t := &Tracer{tracer: tracerProvider.Tracer(ScopeName)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.propagator == nil {
+		t.propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return t
+}
+
+// Run runs req with client in a span. The span records the source, page and
+// limit variables of the request, the HTTP status code and the number of
+// GraphQL errors of the response, and the error returned, if any.
+func (t *Tracer) Run(ctx context.Context, client *graphql.Client, req *graphql.Request, resp interface{}) error {
+	return t.Do(ctx, req, func(ctx context.Context) error {
+		return client.Run(ctx, req, resp)
+	})
+}
+
+// Do runs an operation sending req, which may take several attempts, in a
+// single span recording the outcome of run as Run does. Attempts that are
+// retried are recorded with RecordRetry.
+func (t *Tracer) Do(ctx context.Context, req *graphql.Request, run func(ctx context.Context) error) error {
+	opType, opName := operation(req.Query())
+	spanName := opName
+	if spanName == "" {
+		spanName = "graphql " + opType
+	}
+
+	ctx, span := t.tracer.Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(requestAttributes(opType, opName, req.Vars())...),
+	)
+	defer span.End()
+
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	err := run(ctx)
+	end(span, err)
+	return err
+}
+
+// RecordRetry adds a retry event to the span of ctx, if any, for an attempt
+// that failed with err and is retried after wait.
+func RecordRetry(ctx context.Context, attempt int, err error, wait time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		AttemptKey.Int(attempt),
+		attribute.String("exception.message", err.Error()),
+		RetryWaitKey.String(wait.String()),
+	}
+	var statusErr *graphql.StatusError
+	if errors.As(err, &statusErr) {
+		attrs = append(attrs, StatusCodeKey.Int(statusErr.StatusCode))
+	}
+	span.AddEvent("retry", trace.WithAttributes(attrs...))
+}
+
+func requestAttributes(opType, opName string, vars map[string]interface{}) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{OperationTypeKey.String(opType)}
+	if opName != "" {
+		attrs = append(attrs, OperationNameKey.String(opName))
+	}
+	if source, ok := stringValue(vars["source"]); ok {
+		attrs = append(attrs, SourceKey.String(source))
+	}
+	if page, ok := intValue(vars["page"]); ok {
+		attrs = append(attrs, PageKey.Int64(page))
+	}
+	if limit, ok := intValue(vars["limit"]); ok {
+		attrs = append(attrs, LimitKey.Int64(limit))
+	}
+	return attrs
+}
+
+// end records the outcome of a request on its span.
+func end(span trace.Span, err error) {
+	var gqlErrs graphql.GraphQLErrors
+	errors.As(err, &gqlErrs)
+	span.SetAttributes(ErrorCountKey.Int(len(gqlErrs)))
+
+	var statusErr *graphql.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		span.SetAttributes(StatusCodeKey.Int(statusErr.StatusCode))
+	case err == nil || gqlErrs != nil:
+		// The client reports other statuses as a StatusError.
+		span.SetAttributes(StatusCodeKey.Int(http.StatusOK))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// operation returns the type and name of the operation of query. The name is
+// empty for anonymous operations.
+func operation(query string) (string, string) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return string(ast.Query), ""
+	}
+	op := doc.Operations[0]
+	return string(op.Operation), op.Name
+}
+
+// stringValue returns variables of a string kind, such as the generated
+// Source enum, as strings.
+func stringValue(value interface{}) (string, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// intValue returns variables of an integer kind, such as graphqlTypes.Int64,
+// as int64.
+func intValue(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,217 @@
+package otelgraphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const getActivityQuery = `query getActivity ($dataKey: String!, $source: Source!, $limit: Int64!, $page: Int64!) {
+	getActivity(dataKey: $dataKey, source: $source, limit: $limit, page: $page) { total }
+}`
+
+type source string
+
+type int64Var int64
+
+// newTestServer starts a server answering every request with status and
+// body, and returns the traceparent headers it received.
+func newTestServer(t *testing.T, status int, body string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &traceparents
+}
+
+func newTestTracer() (*Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return NewTracer(provider), recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestRun(t *testing.T) {
+	server, traceparents := newTestServer(t, http.StatusOK, `{"data":{"getActivity":{"total":3}}}`)
+	tracer, recorder := newTestTracer()
+
+	req := graphql.NewRequest(getActivityQuery)
+	req.Var("dataKey", "MY_DATA_KEY")
+	req.Var("source", source("NETFLIX"))
+	req.Var("limit", int64Var(10))
+	req.Var("page", int64Var(2))
+	var resp map[string]interface{}
+	if err := tracer.Run(context.Background(), graphql.NewClient(server.URL), req, &resp); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Run() ended %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "getActivity" {
+		t.Errorf("span name = %s, want getActivity", span.Name())
+	}
+	attrs := attributes(span)
+	for key, want := range map[attribute.Key]attribute.Value{
+		OperationNameKey: attribute.StringValue("getActivity"),
+		OperationTypeKey: attribute.StringValue("query"),
+		SourceKey:        attribute.StringValue("NETFLIX"),
+		PageKey:          attribute.Int64Value(2),
+		LimitKey:         attribute.Int64Value(10),
+		StatusCodeKey:    attribute.IntValue(http.StatusOK),
+		ErrorCountKey:    attribute.IntValue(0),
+	} {
+		if got := attrs[key]; got != want {
+			t.Errorf("span attribute %s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+	if span.Status().Code == codes.Error {
+		t.Errorf("span status = %v, want no error", span.Status())
+	}
+
+	traceID := span.SpanContext().TraceID().String()
+	if len(*traceparents) != 1 || len((*traceparents)[0]) < 35 || (*traceparents)[0][3:35] != traceID {
+		t.Errorf("traceparent headers = %v, want trace %s", *traceparents, traceID)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantErrors int
+	}{
+		{
+			name:       "GraphQL errors",
+			status:     http.StatusOK,
+			body:       `{"data":null,"errors":[{"message":"data key not found"},{"message":"invalid source"}]}`,
+			wantStatus: http.StatusOK,
+			wantErrors: 2,
+		},
+		{
+			name:       "partial data",
+			status:     http.StatusOK,
+			body:       `{"data":{"getActivity":null},"errors":[{"message":"data key not found"}]}`,
+			wantStatus: http.StatusOK,
+			wantErrors: 1,
+		},
+		{
+			name:       "unavailable",
+			status:     http.StatusServiceUnavailable,
+			body:       `Service Unavailable`,
+			wantStatus: http.StatusServiceUnavailable,
+			wantErrors: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, tt.status, tt.body)
+			tracer, recorder := newTestTracer()
+
+			var resp map[string]interface{}
+			if err := tracer.Run(context.Background(), graphql.NewClient(server.URL), graphql.NewRequest(getActivityQuery), &resp); err == nil {
+				t.Fatal("Run() expected an error")
+			}
+
+			span := recorder.Ended()[0]
+			attrs := attributes(span)
+			if got := attrs[StatusCodeKey].AsInt64(); got != int64(tt.wantStatus) {
+				t.Errorf("span status code = %d, want %d", got, tt.wantStatus)
+			}
+			if got := attrs[ErrorCountKey].AsInt64(); got != int64(tt.wantErrors) {
+				t.Errorf("span error count = %d, want %d", got, tt.wantErrors)
+			}
+			if span.Status().Code != codes.Error {
+				t.Errorf("span status = %v, want an error", span.Status())
+			}
+		})
+	}
+}
+
+func TestRunNetworkError(t *testing.T) {
+	server, _ := newTestServer(t, http.StatusOK, `{}`)
+	server.Close()
+	tracer, recorder := newTestTracer()
+
+	if err := tracer.Run(context.Background(), graphql.NewClient(server.URL), graphql.NewRequest(`{ __typename }`), nil); err == nil {
+		t.Fatal("Run() expected an error")
+	}
+
+	span := recorder.Ended()[0]
+	if span.Name() != "graphql query" {
+		t.Errorf("span name = %s, want graphql query for an anonymous query", span.Name())
+	}
+	if _, ok := attributes(span)[StatusCodeKey]; ok {
+		t.Error("span has a status code without a response")
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	server, traceparents := newTestServer(t, http.StatusOK, `{"data":{"getActivity":{"total":3}}}`)
+	tracer, recorder := newTestTracer()
+	client := graphql.NewClient(server.URL)
+	req := graphql.NewRequest(getActivityQuery)
+
+	err := tracer.Do(context.Background(), req, func(ctx context.Context) error {
+		for attempt := 1; attempt < 3; attempt++ {
+			RecordRetry(ctx, attempt, &graphql.StatusError{StatusCode: http.StatusServiceUnavailable}, time.Millisecond)
+		}
+		var resp map[string]interface{}
+		return client.Run(ctx, req, &resp)
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Do() ended %d spans, want 1 for the operation", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("span has %d events, want 2 retries", len(events))
+	}
+	for i, event := range events {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range event.Attributes {
+			attrs[attr.Key] = attr.Value
+		}
+		if event.Name != "retry" || attrs[AttemptKey].AsInt64() != int64(i+1) || attrs[StatusCodeKey].AsInt64() != http.StatusServiceUnavailable {
+			t.Errorf("event %d = %s %v, want retry of attempt %d", i, event.Name, event.Attributes, i+1)
+		}
+	}
+	if len(*traceparents) != 1 {
+		t.Errorf("server received %d requests, want 1", len(*traceparents))
+	}
+}
+
+func TestRecordRetryWithoutSpan(t *testing.T) {
+	// Recording outside of a span does nothing.
+	RecordRetry(context.Background(), 1, &graphql.StatusError{StatusCode: http.StatusBadGateway}, time.Second)
+}
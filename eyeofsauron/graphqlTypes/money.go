@@ -0,0 +1,289 @@
+package graphqlTypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Money is an exact decimal amount in a currency. The zero value is an amount
+// of zero in no particular currency.
+type Money struct {
+	// Currency is the ISO 4217 code of the currency, or empty when the
+	// amount did not name one.
+	Currency string
+
+	// units is the amount in 10^-scale of the currency.
+	units int64
+	scale int
+}
+
+// currencySymbols maps the currency symbols found in amounts to ISO 4217
+// codes, longest first so that US$ is matched before $.
+var currencySymbols = []struct {
+	symbol   string
+	currency string
+}{
+	{"US$", "USD"},
+	{"CA$", "CAD"},
+	{"AU$", "AUD"},
+	{"C$", "CAD"},
+	{"A$", "AUD"},
+	{"R$", "BRL"},
+	{"$", "USD"},
+	{"£", "GBP"},
+	{"€", "EUR"},
+	{"¥", "JPY"},
+	{"₹", "INR"},
+	{"₩", "KRW"},
+}
+
+// NewMoney returns the amount units × 10^-scale in currency.
+func NewMoney(units int64, scale int, currency string) Money {
+	return Money{Currency: currency, units: units, scale: scale}
+}
+
+// ParseMoney parses an amount as returned by Sauron, such as "$12.99",
+// "12.99 USD", "EUR 1,234.50", "12,99 €" or "-4.20". Currency symbols are
+// converted to ISO 4217 codes; "$" is taken to be USD. Amounts without a
+// currency have an empty Currency.
+func ParseMoney(s string) (Money, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return Money{}, fmt.Errorf("invalid amount %q: empty", s)
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+	if strings.HasPrefix(value, "-") {
+		negative = !negative
+		value = strings.TrimSpace(value[1:])
+	}
+
+	value, currency := cutCurrency(value)
+	if strings.HasPrefix(value, "-") {
+		negative = !negative
+		value = strings.TrimSpace(value[1:])
+	}
+
+	units, scale, err := parseDecimal(value)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if negative {
+		units = -units
+	}
+	return Money{Currency: currency, units: units, scale: scale}, nil
+}
+
+// cutCurrency removes a currency symbol or ISO code from the start or end of
+// the amount and returns the ISO code.
+func cutCurrency(value string) (string, string) {
+	for _, symbol := range currencySymbols {
+		if rest, ok := strings.CutPrefix(value, symbol.symbol); ok {
+			return strings.TrimSpace(rest), symbol.currency
+		}
+		if rest, ok := strings.CutSuffix(value, symbol.symbol); ok {
+			return strings.TrimSpace(rest), symbol.currency
+		}
+	}
+
+	if len(value) > 3 && isCurrencyCode(value[:3]) {
+		return strings.TrimSpace(value[3:]), strings.ToUpper(value[:3])
+	}
+	if len(value) > 3 && isCurrencyCode(value[len(value)-3:]) {
+		return strings.TrimSpace(value[:len(value)-3]), strings.ToUpper(value[len(value)-3:])
+	}
+	return value, ""
+}
+
+func isCurrencyCode(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) || r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDecimal parses digits with optional thousands separators and decimal
+// point. When both ',' and '.' appear, the last one is the decimal point;
+// a lone ',' is the decimal point only when followed by one or two digits.
+func parseDecimal(value string) (int64, int, error) {
+	decimal := -1
+	lastComma, lastDot := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+	switch {
+	case lastDot > lastComma:
+		decimal = lastDot
+	case lastComma >= 0 && lastDot >= 0:
+		decimal = lastComma
+	case lastComma >= 0 && len(value)-lastComma-1 <= 2 && strings.Count(value, ",") == 1:
+		decimal = lastComma
+	}
+
+	integer, fraction := value, ""
+	if decimal >= 0 {
+		integer, fraction = value[:decimal], value[decimal+1:]
+	}
+
+	// Thousands separators must split the integer part into groups of three.
+	groups := strings.FieldsFunc(integer, func(r rune) bool {
+		return r == ',' || r == '.' || r == ' '
+	})
+	if len(groups) > 1 || len(groups) == 1 && groups[0] != integer {
+		if strings.Join(groups, "") == "" || len(groups[0]) > 3 {
+			return 0, 0, fmt.Errorf("misplaced thousands separator")
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, 0, fmt.Errorf("misplaced thousands separator")
+			}
+		}
+	}
+
+	digits := strings.Join(groups, "") + fraction
+	if digits == "" {
+		return 0, 0, fmt.Errorf("no digits")
+	}
+
+	var units int64
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, 0, fmt.Errorf("unexpected %q", r)
+		}
+		if units > (math.MaxInt64-9)/10 {
+			return 0, 0, fmt.Errorf("out of range")
+		}
+		units = units*10 + int64(r-'0')
+	}
+	return units, len(fraction), nil
+}
+
+// MoneyFromFloat converts an amount reported as a number, such as
+// UberEatsActivityMetadata.TotalPrice, using the shortest decimal
+// representation of the float.
+func MoneyFromFloat(amount float64, currency string) (Money, error) {
+	m, err := ParseMoney(strconv.FormatFloat(amount, 'f', -1, 64))
+	if err != nil {
+		return Money{}, err
+	}
+	m.Currency = strings.ToUpper(currency)
+	return m, nil
+}
+
+// Units returns the amount as units × 10^-scale.
+func (m Money) Units() (units int64, scale int) {
+	return m.units, m.scale
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+// Amount returns the amount as a decimal string, such as "-12.50".
+func (m Money) Amount() string {
+	digits := strconv.FormatInt(m.units, 10)
+	sign := ""
+	if m.units < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if m.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= m.scale {
+		digits = strings.Repeat("0", m.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-m.scale] + "." + digits[len(digits)-m.scale:]
+}
+
+// Float64 returns the amount as a float, which may not be exact.
+func (m Money) Float64() float64 {
+	return float64(m.units) / math.Pow10(m.scale)
+}
+
+// Add returns the sum of two amounts in the same currency. An amount without
+// a currency can only be added to another without one, except for the zero
+// value, so that totals can start from Money{}.
+func (m Money) Add(other Money) (Money, error) {
+	if m == (Money{}) {
+		return other, nil
+	}
+	if other == (Money{}) {
+		return m, nil
+	}
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other, m)
+	}
+
+	a, b := m, other
+	for a.scale < b.scale {
+		if a.units > math.MaxInt64/10 || a.units < math.MinInt64/10 {
+			return Money{}, fmt.Errorf("cannot add %s to %s: out of range", other, m)
+		}
+		a.units *= 10
+		a.scale++
+	}
+	for b.scale < a.scale {
+		if b.units > math.MaxInt64/10 || b.units < math.MinInt64/10 {
+			return Money{}, fmt.Errorf("cannot add %s to %s: out of range", other, m)
+		}
+		b.units *= 10
+		b.scale++
+	}
+
+	sum := a.units + b.units
+	if (sum > a.units) != (b.units > 0) {
+		return Money{}, fmt.Errorf("cannot add %s to %s: out of range", other, m)
+	}
+	return Money{Currency: m.Currency, units: sum, scale: a.scale}, nil
+}
+
+// String returns the amount followed by the currency, such as "12.99 USD".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount()
+	}
+	return m.Amount() + " " + m.Currency
+}
+
+// MarshalJSON encodes the amount as {"amount":"12.99","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency,omitempty"`
+	}{m.Amount(), m.Currency})
+}
+
+// UnmarshalJSON decodes an amount encoded by MarshalJSON. The amount keeps
+// its precision, so "12.50" stays in hundredths of the currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var v struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("money must be an object: %w", err)
+	}
+
+	money, err := ParseMoney(v.Amount)
+	if err != nil {
+		return err
+	}
+	if money.Currency != "" {
+		return fmt.Errorf("invalid amount %q: currency belongs in the currency field", v.Amount)
+	}
+	money.Currency = v.Currency
+	*m = money
+	return nil
+}
@@ -0,0 +1,121 @@
+package graphqlTypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		amount   string
+		currency string
+		wantErr  bool
+	}{
+		{input: "$12.99", amount: "12.99", currency: "USD"},
+		{input: "12.99 USD", amount: "12.99", currency: "USD"},
+		{input: "EUR 1,234.50", amount: "1234.50", currency: "EUR"},
+		{input: "12,99 €", amount: "12.99", currency: "EUR"},
+		{input: "1.234,56€", amount: "1234.56", currency: "EUR"},
+		{input: "£1,234", amount: "1234", currency: "GBP"},
+		{input: "CA$7.5", amount: "7.5", currency: "CAD"},
+		{input: "-$4.20", amount: "-4.20", currency: "USD"},
+		{input: "($4.20)", amount: "-4.20", currency: "USD"},
+		{input: " 0.05 ", amount: "0.05"},
+		{input: "", wantErr: true},
+		{input: "$", wantErr: true},
+		{input: "free", wantErr: true},
+		{input: "12.34.56", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Amount() != tt.amount || got.Currency != tt.currency {
+				t.Errorf("ParseMoney() = %s %s, want %s %s", got.Amount(), got.Currency, tt.amount, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	total := Money{}
+	for _, amount := range []string{"$12.99", "$0.01", "$100"} {
+		m, err := ParseMoney(amount)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) error = %v", amount, err)
+		}
+		if total, err = total.Add(m); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if got := total.String(); got != "113.00 USD" {
+		t.Errorf("total = %s, want 113.00 USD", got)
+	}
+
+	if _, err := total.Add(NewMoney(1, 0, "EUR")); err == nil {
+		t.Error("Add() expected an error for different currencies")
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	got, err := MoneyFromFloat(23.4, "usd")
+	if err != nil {
+		t.Fatalf("MoneyFromFloat() error = %v", err)
+	}
+	if got.String() != "23.4 USD" {
+		t.Errorf("MoneyFromFloat() = %s, want 23.4 USD", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		money Money
+		json  string
+	}{
+		{money: NewMoney(1250, 2, "USD"), json: `{"amount":"12.50","currency":"USD"}`},
+		{money: NewMoney(-420, 2, "EUR"), json: `{"amount":"-4.20","currency":"EUR"}`},
+		{money: NewMoney(5, 3, ""), json: `{"amount":"0.005"}`},
+		{money: NewMoney(1500, 0, "JPY"), json: `{"amount":"1500","currency":"JPY"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			data, err := json.Marshal(tt.money)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("Marshal() = %s, want %s", data, tt.json)
+			}
+
+			var got Money
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got != tt.money {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.money)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSONErrors(t *testing.T) {
+	for _, input := range []string{
+		`"12.50 USD"`,
+		`{"amount":""}`,
+		`{"amount":"twelve","currency":"USD"}`,
+		`{"amount":"$12.50","currency":"USD"}`,
+	} {
+		var m Money
+		if err := json.Unmarshal([]byte(input), &m); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want an error", input, m)
+		}
+	}
+}
@@ -58,8 +58,10 @@ type Value struct {
 func main() {
 
 	var folder string
+	var tracing bool
 	flag.StringVar(&folder, "folder", "", "Set the destination folder for the generated files")
 	flag.StringVar(&folder, "f", "", "Set the destination folder for the generated files")
+	flag.BoolVar(&tracing, "tracing", false, "Add OpenTelemetry tracing to the generated client")
 	flag.Parse()
 
 	cwd, err := os.Getwd()
@@ -123,7 +125,33 @@ func main() {
 		log.Fatalf("unable to generate code: %s", err)
 	}
 
+	templateData := buildTemplateData(config.Package, respData)
+	templateData.Tracing = tracing
+	templateData.MoneyFields, err = findMoneyFields(generated[config.Generated])
+	if err != nil {
+		log.Fatalf("unable to generate client: %s", err)
+	}
+	templateData.TraitTypes, err = findTraitTypes(generated[config.Generated])
+	if err != nil {
+		log.Fatalf("unable to generate client: %s", err)
+	}
+
+	clientFiles, err := renderTemplates(templateData)
+	if err != nil {
+		log.Fatalf("unable to generate client: %s", err)
+	}
+	for filename, content := range clientFiles {
+		generated[filepath.Join(filepath.Dir(config.Generated), filename)] = content
+	}
+
 	for filename, content := range generated {
+		if filename == config.Generated {
+			content, err = rewriteGenerated(content)
+			if err != nil {
+				log.Fatalf("unable to rewrite generated code: %s", err)
+			}
+		}
+
 		err = os.MkdirAll(filepath.Dir(filename), 0o755)
 		if err != nil {
 			log.Fatalf("could not create parent directory for generated file %v: %v", filename, err)
@@ -0,0 +1,479 @@
+// Package recorder records the requests made by the generated EyeOfSauron
+// client and the responses of Sauron into a cassette file, and replays them,
+// so that tests can run against real responses without network.
+//
+// A Recorder is an http.RoundTripper. Record the interactions once with a
+// real private key:
+//
+//	rec, err := recorder.New("testdata/sauron.json", recorder.ModeRecord)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	eye, err := generated.NewEyeOfSauron(privateKey, generated.WithHTTPClient(rec.Client()))
+//	// ... make the calls to record ...
+//	err = rec.Stop()
+//
+// and replay them in CI with ModeReplay. Interactions are keyed by operation
+// name and variables. Data keys are redacted from the cassette, so replays
+// match whatever data key the test uses, and request signatures are never
+// written.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Redacted replaces redacted values in cassettes.
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned by a replaying Recorder for requests the
+// cassette holds no interaction for.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette without network.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to Sauron and writes the interactions to the
+	// cassette on Stop, replacing it.
+	ModeRecord
+)
+
+// Matching selects how a replaying Recorder matches requests to interactions.
+type Matching int
+
+const (
+	// MatchStrict replays each interaction once, in recorded order, for a
+	// request with the same operation name, query and variables.
+	MatchStrict Matching = iota
+	// MatchLenient replays interactions any number of times. A request with
+	// the same operation name and variables gets the last such interaction;
+	// otherwise it gets the last interaction of the same operation.
+	MatchLenient
+)
+
+// Cassette holds the recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	// Operation is the name of the GraphQL operation, such as getActivity.
+	Operation string                 `json:"operation"`
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Response  Response               `json:"response"`
+
+	key  string
+	used bool
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	// Body holds JSON bodies and Text any other body, such as the plain text
+	// error of a gateway.
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying interactions.
+type Recorder struct {
+	path      string
+	mode      Mode
+	matching  Matching
+	transport http.RoundTripper
+	redact    map[string]bool
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// Option configures a Recorder created by New.
+type Option func(*Recorder)
+
+// WithMatching sets how requests are matched to interactions when replaying.
+// The default is MatchStrict.
+func WithMatching(matching Matching) Option {
+	return func(r *Recorder) {
+		r.matching = matching
+	}
+}
+
+// WithTransport sends the recorded requests with transport instead of
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedactedVariables redacts the values of the named variables, and of
+// the response fields of the same names, in addition to dataKey.
+func WithRedactedVariables(names ...string) Option {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.redact[name] = true
+		}
+	}
+}
+
+// New returns a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		redact:    map[string]bool{"dataKey": true},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch mode {
+	case ModeRecord:
+	case ModeReplay:
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		for i := range cassette.Interactions {
+			interaction := &cassette.Interactions[i]
+			interaction.key, err = key(interaction.Query, interaction.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("interaction %d of %s: %w", i, path, err)
+			}
+			r.interactions = append(r.interactions, interaction)
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	return r, nil
+}
+
+// LoadCassette reads the cassette at path.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := decodeJSON(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Client returns an HTTP client using the Recorder, for WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the recorded interactions to the cassette. It does nothing
+// when replaying.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	cassette := Cassette{Interactions: []Interaction{}}
+	for _, interaction := range r.interactions {
+		cassette.Interactions = append(cassette.Interactions, *interaction)
+	}
+	r.mu.Unlock()
+
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	query, variables, err := parseRequest(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+	r.redactVariables(variables)
+
+	interaction := &Interaction{
+		Operation: operationName(query),
+		Query:     query,
+		Variables: variables,
+	}
+	if interaction.key, err = key(query, variables); err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+
+	if r.mode == ModeReplay {
+		recorded := r.match(interaction)
+		if recorded == nil {
+			return nil, fmt.Errorf("recorder: %w for %s with variables %s", ErrNoInteraction, interaction.Operation, mustMarshal(variables))
+		}
+		return recorded.Response.toHTTP(req), nil
+	}
+
+	// The request body was consumed above, so send a copy of the request.
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	res, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	interaction.Response = newResponse(res, r.redactBody(resBody))
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return res, nil
+}
+
+// match returns the interaction to replay for a request, or nil.
+func (r *Recorder) match(req *Interaction) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.matching == MatchStrict {
+		for _, interaction := range r.interactions {
+			if !interaction.used && interaction.key == req.key {
+				interaction.used = true
+				return interaction
+			}
+		}
+		return nil
+	}
+
+	var sameOperation *Interaction
+	for i := len(r.interactions) - 1; i >= 0; i-- {
+		interaction := r.interactions[i]
+		if interaction.Operation != req.Operation {
+			continue
+		}
+		if variablesKey(interaction.Variables) == variablesKey(req.Variables) {
+			return interaction
+		}
+		if sameOperation == nil {
+			sameOperation = interaction
+		}
+	}
+	return sameOperation
+}
+
+// redactVariables replaces the values of the redacted variables.
+func (r *Recorder) redactVariables(variables map[string]interface{}) {
+	for name, value := range variables {
+		if r.redact[name] && value != nil {
+			variables[name] = Redacted
+		}
+	}
+}
+
+// redactBody replaces the values of the fields of a JSON response named after
+// a redacted variable, such as dataKey. Other bodies, and bodies without such
+// fields, are returned unchanged.
+func (r *Recorder) redactBody(body []byte) []byte {
+	var value interface{}
+	if decodeJSON(body, &value) != nil || !r.redactFields(value) {
+		return body
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactFields redacts the fields of value named after a redacted variable,
+// and reports whether it found any.
+func (r *Recorder) redactFields(value interface{}) bool {
+	redacted := false
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, field := range value {
+			if r.redact[name] && field != nil {
+				value[name] = Redacted
+				redacted = true
+			} else if r.redactFields(field) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if r.redactFields(item) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+func newResponse(res *http.Response, body []byte) Response {
+	recorded := Response{StatusCode: res.StatusCode, Header: res.Header.Clone()}
+	// Content-Length no longer matches a compacted body, and Date and cookies
+	// would change the cassette on every recording.
+	for _, name := range []string{"Content-Length", "Date", "Set-Cookie"} {
+		recorded.Header.Del(name)
+	}
+	if json.Valid(body) {
+		var compact bytes.Buffer
+		json.Compact(&compact, body)
+		recorded.Body = compact.Bytes()
+	} else {
+		recorded.Text = string(body)
+	}
+	return recorded
+}
+
+func (res Response) toHTTP(req *http.Request) *http.Response {
+	body := []byte(res.Text)
+	if len(res.Body) > 0 {
+		body = res.Body
+	}
+	header := res.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// readBody reads and closes the body of req, as RoundTrip must.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: reading request body: %w", err)
+	}
+	return body, nil
+}
+
+// parseRequest returns the query and variables of a GraphQL request sent as
+// JSON or, with graphql.UseMultipartForm, as a multipart form.
+func parseRequest(contentType string, body []byte) (string, map[string]interface{}, error) {
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		if err := decodeJSON(body, &request); err != nil {
+			return "", nil, fmt.Errorf("invalid GraphQL request: %w", err)
+		}
+		return request.Query, request.Variables, nil
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid GraphQL request: %w", err)
+	}
+	defer form.RemoveAll()
+	if values := form.Value["query"]; len(values) > 0 {
+		request.Query = values[0]
+	}
+	if values := form.Value["variables"]; len(values) > 0 {
+		if err := decodeJSON([]byte(values[0]), &request.Variables); err != nil {
+			return "", nil, fmt.Errorf("invalid GraphQL variables: %w", err)
+		}
+	}
+	return request.Query, request.Variables, nil
+}
+
+// operationName returns the name of the operation of query, or the name of
+// its first field for anonymous operations.
+func operationName(query string) string {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return ""
+	}
+	op := doc.Operations[0]
+	if op.Name != "" {
+		return op.Name
+	}
+	for _, selection := range op.SelectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			return field.Name
+		}
+	}
+	return ""
+}
+
+// key identifies the requests an interaction is replayed for under
+// MatchStrict.
+func key(query string, variables map[string]interface{}) (string, error) {
+	data, err := json.Marshal(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}{query, variables})
+	if err != nil {
+		return "", fmt.Errorf("invalid variables: %w", err)
+	}
+	return string(data), nil
+}
+
+func variablesKey(variables map[string]interface{}) string {
+	return string(mustMarshal(variables))
+}
+
+// mustMarshal encodes values decoded from JSON, which cannot fail.
+func mustMarshal(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// decodeJSON decodes data keeping numbers as json.Number, so that variables
+// encode the same way whether they come from a request or a cassette.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
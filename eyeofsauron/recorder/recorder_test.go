@@ -0,0 +1,212 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+	"github.com/gandalf-network/gandalf-sdk-go/generated"
+	"github.com/google/uuid"
+)
+
+const dataKey = "MY_DATA_KEY"
+
+var traitID = uuid.MustParse("6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a03")
+
+func newClient(t *testing.T, rec *Recorder, endpoint string) *generated.EyeOfSauron {
+	t.Helper()
+
+	eye, err := generated.NewEyeOfSauron(saurontest.PrivateKey,
+		generated.WithEndpoint(endpoint),
+		generated.WithHTTPClient(rec.Client()),
+		generated.WithRetryPolicy(generated.RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	return eye
+}
+
+// record records a cassette against a saurontest server and returns its path.
+func record(t *testing.T) string {
+	t.Helper()
+
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps: []saurontest.App{saurontest.TestApp},
+		Traits: map[string][]saurontest.Trait{dataKey: {
+			{ID: traitID.String(), Source: "NETFLIX", Label: "PLAN", Value: "premium", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		}},
+	})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "testdata", "sauron.json")
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	eye := newClient(t, rec, server.URL)
+	ctx := context.Background()
+
+	if _, err := eye.GetTraits(ctx, dataKey, generated.SourceNetflix, []generated.TraitLabel{generated.TraitLabelPlan}); err != nil {
+		t.Fatalf("GetTraits() error = %v", err)
+	}
+	server.Inject("lookupTrait", saurontest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); err == nil {
+		t.Fatal("LookupTrait() expected the injected error")
+	}
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	return path
+}
+
+func TestRecordRedacts(t *testing.T) {
+	path := record(t)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{dataKey, "X-Gandalf-Signature"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %s:\n%s", secret, data)
+		}
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	var operations []string
+	for _, interaction := range cassette.Interactions {
+		operations = append(operations, interaction.Operation)
+	}
+	if got, want := strings.Join(operations, ","), "getTraits,lookupTrait,lookupTrait"; got != want {
+		t.Errorf("recorded operations = %s, want %s", got, want)
+	}
+	if got := cassette.Interactions[0].Variables["dataKey"]; got != Redacted {
+		t.Errorf("recorded dataKey = %v, want %s", got, Redacted)
+	}
+	if got := cassette.Interactions[1].Response; got.StatusCode != http.StatusServiceUnavailable || got.Text == "" {
+		t.Errorf("recorded error response = %+v", got)
+	}
+}
+
+func TestReplayStrict(t *testing.T) {
+	path := record(t)
+
+	rec, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// Nothing listens on the endpoint: every response comes from the cassette.
+	eye := newClient(t, rec, "http://127.0.0.1:1/public/gql")
+	ctx := context.Background()
+
+	traits, err := eye.GetTraits(ctx, "OTHER_DATA_KEY", generated.SourceNetflix, []generated.TraitLabel{generated.TraitLabelPlan})
+	if err != nil {
+		t.Fatalf("GetTraits() error = %v", err)
+	}
+	if got := traits.GetGetTraits(); len(got) != 1 || got[0].Value != "premium" {
+		t.Errorf("GetTraits() = %+v, want the recorded PLAN trait", got)
+	}
+
+	var statusErr *graphql.StatusError
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("LookupTrait() error = %v, want the recorded 503", err)
+	}
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); err != nil {
+		t.Errorf("LookupTrait() error = %v", err)
+	}
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("LookupTrait() error = %v, want %v once the interactions are used", err, ErrNoInteraction)
+	}
+	if _, err := eye.GetTraits(ctx, dataKey, generated.SourceNetflix, []generated.TraitLabel{generated.TraitLabelRating}); err == nil {
+		t.Error("GetTraits() with other labels expected an error")
+	}
+}
+
+func TestReplayLenient(t *testing.T) {
+	path := record(t)
+
+	rec, err := New(path, ModeReplay, WithMatching(MatchLenient))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	eye := newClient(t, rec, "http://127.0.0.1:1/public/gql")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := eye.LookupTrait(ctx, dataKey, traitID); err != nil {
+			t.Errorf("LookupTrait() error = %v", err)
+		}
+	}
+	traits, err := eye.GetTraits(ctx, dataKey, generated.SourceNetflix, []generated.TraitLabel{generated.TraitLabelRating})
+	if err != nil {
+		t.Fatalf("GetTraits() with other labels error = %v", err)
+	}
+	if got := traits.GetGetTraits(); len(got) != 1 {
+		t.Errorf("GetTraits() = %+v, want the recorded traits", got)
+	}
+	if _, err := eye.Verify(ctx); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrNoInteraction)
+	}
+}
+
+func TestNewReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("New() expected an error for a missing cassette")
+	}
+}
+
+func TestRecordRedactsOnlyDataKeyFields(t *testing.T) {
+	// The data key is also the value of an unrelated field and a substring of
+	// the message, which must be recorded as they are.
+	const shortDataKey = "PLAN"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"lookupTrait":{"label":"PLAN","value":"PLANET","owner":{"dataKey":"PLAN"}}},"extensions":{"dataKey":"PLAN","count":12345678901234567890}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "sauron.json")
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	eye := newClient(t, rec, server.URL)
+	if _, err := eye.LookupTrait(context.Background(), shortDataKey, traitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	interaction := cassette.Interactions[0]
+	if got := interaction.Variables["dataKey"]; got != Redacted {
+		t.Errorf("recorded dataKey = %v, want %s", got, Redacted)
+	}
+	want := `{"data":{"lookupTrait":{"label":"PLAN","owner":{"dataKey":"REDACTED"},"value":"PLANET"}},"extensions":{"count":12345678901234567890,"dataKey":"REDACTED"}}`
+	var got bytes.Buffer
+	if err := json.Compact(&got, interaction.Response.Body); err != nil || got.String() != want {
+		t.Errorf("recorded body = %s, want %s", got.String(), want)
+	}
+}
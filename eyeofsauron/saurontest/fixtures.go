@@ -0,0 +1,143 @@
+package saurontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// PrivateKey is the private key of TestApp, for clients of a test server:
+//
+//	eye, err := generated.NewEyeOfSauron(saurontest.PrivateKey, generated.WithEndpoint(server.URL))
+const PrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// TestApp is the application registered for PrivateKey. It is the only
+// application of a server created without fixtures.
+var TestApp = App{
+	AppName:      "Test App",
+	PublicKey:    "0x024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e",
+	IconURL:      "https://example.com/icon.png",
+	GandalfID:    1,
+	AppRegistrar: "0x0000000000000000000000000000000000000000",
+}
+
+// Fixtures is the data served by a test server.
+type Fixtures struct {
+	// Apps are the registered applications. Requests for data must be signed
+	// with the private key of one of them.
+	Apps []App `json:"apps"`
+	// Activities and Traits are keyed by data key. Data keys missing from
+	// both are unknown to the server.
+	Activities map[string][]Activity `json:"activities"`
+	Traits     map[string][]Trait    `json:"traits"`
+	// Schema is the SDL of the schema served to introspection queries and
+	// used to shape responses. It defaults to the Sauron schema.
+	Schema string `json:"schema"`
+}
+
+// App is a registered application, returned by getAppByPublicKey.
+type App struct {
+	AppName string `json:"appName"`
+	// PublicKey is the compressed public key in hexadecimal, such as
+	// connect.Config.PublicKey.
+	PublicKey    string `json:"publicKey"`
+	IconURL      string `json:"iconURL"`
+	GandalfID    int64  `json:"gandalfID"`
+	AppRegistrar string `json:"appRegistrar"`
+}
+
+// Activity is an activity returned by getActivity and lookupActivity.
+type Activity struct {
+	ID string `json:"id"`
+	// Source is matched against the source argument of getActivity. It
+	// defaults to the metadata type without its ActivityMetadata suffix, such
+	// as NETFLIX for NetflixActivityMetadata.
+	Source string `json:"source"`
+	// Type is matched against the activityType argument of getActivity.
+	// Activities without a type match every type.
+	Type string `json:"type"`
+	// Metadata is the metadata object, whose __typename names its type, such
+	// as {"__typename":"NetflixActivityMetadata","title":"Arcane"}.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// Trait is a trait returned by getTraits and lookupTrait.
+type Trait struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Label     string    `json:"label"`
+	Value     string    `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LoadFixturesFile reads fixtures from a JSON file holding a Fixtures object.
+func LoadFixturesFile(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures in %s: %w", path, err)
+	}
+	return &fixtures, nil
+}
+
+// metadataTypename returns the __typename of the metadata of an activity.
+func (a Activity) metadataTypename() (string, error) {
+	var metadata struct {
+		Typename string `json:"__typename"`
+	}
+	if err := json.Unmarshal(a.Metadata, &metadata); err != nil {
+		return "", fmt.Errorf("activity %s: invalid metadata: %w", a.ID, err)
+	}
+	if metadata.Typename == "" {
+		return "", fmt.Errorf("activity %s: metadata has no __typename", a.ID)
+	}
+	return metadata.Typename, nil
+}
+
+// source returns the source of the activity, derived from its metadata type
+// unless given.
+func (a Activity) source() string {
+	if a.Source != "" {
+		return a.Source
+	}
+	typename, _ := a.metadataTypename()
+	return strings.ToUpper(strings.TrimSuffix(typename, "ActivityMetadata"))
+}
+
+// object returns the activity as a GraphQL object.
+func (a Activity) object() map[string]interface{} {
+	var metadata interface{}
+	decodeJSON(a.Metadata, &metadata)
+	return map[string]interface{}{
+		"__typename": "Activity",
+		"id":         a.ID,
+		"metadata":   metadata,
+	}
+}
+
+func (t Trait) object() map[string]interface{} {
+	return map[string]interface{}{
+		"__typename": "Trait",
+		"id":         t.ID,
+		"source":     t.Source,
+		"label":      t.Label,
+		"value":      t.Value,
+		"timestamp":  t.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+func (a App) object() map[string]interface{} {
+	return map[string]interface{}{
+		"__typename":   "Application",
+		"appName":      a.AppName,
+		"publicKey":    a.PublicKey,
+		"iconURL":      a.IconURL,
+		"gandalfID":    a.GandalfID,
+		"appRegistrar": a.AppRegistrar,
+	}
+}
@@ -0,0 +1,577 @@
+// Package saurontest provides an in-process stand-in for Sauron, for tests of
+// code using the generated EyeOfSauron client or connect without network.
+//
+// A Server answers the getActivity, lookupActivity, getTraits, lookupTrait
+// and getAppByPublicKey queries from fixtures, as well as introspection
+// queries, and verifies request signatures as Sauron does:
+//
+//	server := saurontest.NewServer(&saurontest.Fixtures{
+//		Apps: []saurontest.App{saurontest.TestApp},
+//		Activities: map[string][]saurontest.Activity{
+//			"MY_DATA_KEY": {{
+//				ID:       "6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a01",
+//				Type:     "WATCH",
+//				Metadata: json.RawMessage(`{"__typename":"NetflixActivityMetadata","title":"Arcane"}`),
+//			}},
+//		},
+//	})
+//	defer server.Close()
+//
+//	eye, err := generated.NewEyeOfSauron(saurontest.PrivateKey, generated.WithEndpoint(server.URL))
+//
+// Errors and latency are injected with Server.Inject.
+package saurontest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/signing"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// maxBodyBytes bounds the request bodies read by the server.
+const maxBodyBytes = 10 << 20
+
+// publicFields are the root fields that Sauron answers without a signature.
+var publicFields = map[string]bool{
+	"__schema":          true,
+	"__type":            true,
+	"__typename":        true,
+	"getAppByPublicKey": true,
+}
+
+// Server is a stand-in for Sauron listening on a local address. Its URL is
+// the GraphQL endpoint.
+type Server struct {
+	*httptest.Server
+
+	schema   *schema
+	fixtures Fixtures
+	verifier *signing.Verifier
+	latency  time.Duration
+
+	mu       sync.Mutex
+	faults   []fault
+	requests []Request
+}
+
+// Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithoutSignatureVerification answers requests for data whether or not
+// they are signed by a registered application.
+func WithoutSignatureVerification() Option {
+	return func(s *Server) {
+		s.verifier = nil
+	}
+}
+
+// WithLatency delays every response.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// Error is a GraphQL error returned by the server.
+type Error struct {
+	Message string
+	// Code is returned as the code extension, such as NOT_FOUND.
+	Code string
+}
+
+// Fault changes how the server answers the requests it applies to.
+type Fault struct {
+	// Latency delays the response.
+	Latency time.Duration
+	// StatusCode answers with this HTTP status instead of running the query,
+	// with Errors if any and a plain text body otherwise.
+	StatusCode int
+	// Header is added to the response, such as a Retry-After header.
+	Header http.Header
+	// Errors answers with these GraphQL errors and no data instead of
+	// running the query.
+	Errors []Error
+	// Times is the number of requests the fault applies to. Zero applies it
+	// to every request.
+	Times int
+}
+
+type fault struct {
+	field string
+	Fault
+}
+
+// Request is a request received by the server.
+type Request struct {
+	// Fields are the root fields of the query, such as getActivity.
+	Fields    []string
+	Variables map[string]interface{}
+	// PublicKey is the registered key that signed the request, or empty if
+	// it was not signed by a registered application.
+	PublicKey string
+}
+
+// NewServer starts a server answering from fixtures. Without fixtures, the
+// server knows TestApp and no data keys. It panics if the fixtures are
+// invalid, as httptest.NewServer does when it cannot listen.
+func NewServer(fixtures *Fixtures, opts ...Option) *Server {
+	if fixtures == nil {
+		fixtures = &Fixtures{Apps: []App{TestApp}}
+	}
+
+	s := &Server{fixtures: *fixtures}
+	if err := s.init(); err != nil {
+		panic("saurontest: " + err.Error())
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *Server) init() error {
+	sdl := s.fixtures.Schema
+	if sdl == "" {
+		sdl = sauronSchema
+	}
+	var err error
+	if s.schema, err = parseSchema(sdl); err != nil {
+		return err
+	}
+
+	for _, activities := range s.fixtures.Activities {
+		for _, activity := range activities {
+			if _, err := activity.metadataTypename(); err != nil {
+				return err
+			}
+		}
+	}
+
+	var publicKeys []string
+	for _, app := range s.fixtures.Apps {
+		publicKeys = append(publicKeys, app.PublicKey)
+	}
+	if len(publicKeys) > 0 {
+		if s.verifier, err = signing.NewVerifier(publicKeys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inject applies fault to the requests for field, such as getActivity, or to
+// every request if field is empty. Faults apply in the order they were
+// injected.
+func (s *Server) Inject(field string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, fault{field: field, Fault: f})
+}
+
+// ClearFaults removes the faults injected with Inject.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// takeFault returns the first fault applying to a request for fields.
+func (s *Server) takeFault(fields []string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		applies := f.field == ""
+		for _, field := range fields {
+			applies = applies || f.field == field
+		}
+		if !applies {
+			continue
+		}
+
+		if f.Times > 0 {
+			s.faults[i].Times--
+			if s.faults[i].Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f.Fault, true
+	}
+	return Fault{}, false
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphqlResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Path       []string               `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func newError(err Error, path ...string) graphqlError {
+	e := graphqlError{Message: err.Message, Path: path}
+	if err.Code != "" {
+		e.Extensions = map[string]interface{}{"code": err.Code}
+	}
+	return e
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "could not read request body", http.StatusBadRequest)
+		return
+	}
+
+	var req graphqlRequest
+	if err := decodeJSON(body, &req); err != nil {
+		writeResponse(w, http.StatusBadRequest, graphqlResponse{Errors: []graphqlError{
+			newError(Error{Message: fmt.Sprintf("invalid request body: %v", err), Code: "BAD_REQUEST"}),
+		}})
+		return
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		writeResponse(w, http.StatusUnprocessableEntity, graphqlResponse{Errors: []graphqlError{
+			newError(Error{Message: err.Error(), Code: "GRAPHQL_PARSE_FAILED"}),
+		}})
+		return
+	}
+	operation := doc.Operations.ForName(req.OperationName)
+	if operation == nil || operation.Operation != ast.Query {
+		writeResponse(w, http.StatusUnprocessableEntity, graphqlResponse{Errors: []graphqlError{
+			newError(Error{Message: "no query to run", Code: "GRAPHQL_VALIDATION_FAILED"}),
+		}})
+		return
+	}
+
+	var fields []*ast.Field
+	var names []string
+	for _, selection := range operation.SelectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			fields = append(fields, field)
+			names = append(names, field.Name)
+		}
+	}
+
+	var publicKey string
+	var signatureErr error = signing.ErrInvalidSignature
+	if s.verifier != nil {
+		publicKey, signatureErr = s.verifier.Verify(body, r.Header.Get(signing.Header))
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Fields: names, Variables: req.Variables, PublicKey: publicKey})
+	s.mu.Unlock()
+
+	f, faulty := s.takeFault(names)
+	if err := sleep(r.Context(), s.latency+f.Latency); err != nil {
+		return
+	}
+	if faulty {
+		for key, values := range f.Header {
+			w.Header()[key] = values
+		}
+		if f.StatusCode != 0 || len(f.Errors) > 0 {
+			status := f.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if len(f.Errors) == 0 {
+				// Answer like a gateway in front of Sauron would.
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			var errs []graphqlError
+			for _, err := range f.Errors {
+				errs = append(errs, newError(err))
+			}
+			writeResponse(w, status, graphqlResponse{Errors: errs})
+			return
+		}
+	}
+
+	if s.verifier != nil && signatureErr != nil {
+		for _, name := range names {
+			if !publicFields[name] {
+				writeResponse(w, http.StatusUnauthorized, graphqlResponse{Errors: []graphqlError{
+					newError(Error{Message: signatureErr.Error(), Code: "UNAUTHENTICATED"}),
+				}})
+				return
+			}
+		}
+	}
+
+	data := make(map[string]interface{})
+	var errs []graphqlError
+	for _, field := range fields {
+		key := field.Alias
+		if key == "" {
+			key = field.Name
+		}
+
+		value, err := s.resolve(field, doc.Fragments, req.Variables)
+		if err != nil {
+			errs = append(errs, newError(*err, key))
+			continue
+		}
+		data[key] = value
+	}
+
+	// Every root field of the schema is non-null, so any error nulls the data.
+	if len(errs) > 0 {
+		writeResponse(w, http.StatusOK, graphqlResponse{Errors: errs})
+		return
+	}
+	writeResponse(w, http.StatusOK, graphqlResponse{Data: data})
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, res graphqlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// resolve answers a root field and shapes the result after its selections.
+func (s *Server) resolve(field *ast.Field, fragments ast.FragmentDefinitionList, vars map[string]interface{}) (interface{}, *Error) {
+	if field.Name == "__typename" {
+		return "Query", nil
+	}
+	if field.Name == "__schema" {
+		value, err := s.schema.project(s.schema.introspection(), "", field.SelectionSet, fragments)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Code: "GRAPHQL_VALIDATION_FAILED"}
+		}
+		return value, nil
+	}
+
+	def := s.schema.types["Query"].Fields.ForName(field.Name)
+	if def == nil {
+		return nil, &Error{Message: fmt.Sprintf("cannot query field %q on type \"Query\"", field.Name), Code: "GRAPHQL_VALIDATION_FAILED"}
+	}
+	args := make(map[string]interface{})
+	for _, arg := range field.Arguments {
+		value, err := arg.Value.Value(vars)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Code: "GRAPHQL_VALIDATION_FAILED"}
+		}
+		args[arg.Name] = value
+	}
+
+	var value interface{}
+	var resolveErr *Error
+	switch field.Name {
+	case "getActivity":
+		value, resolveErr = s.getActivity(args)
+	case "lookupActivity":
+		value, resolveErr = s.lookupActivity(args)
+	case "getTraits":
+		value, resolveErr = s.getTraits(args)
+	case "lookupTrait":
+		value, resolveErr = s.lookupTrait(args)
+	case "getAppByPublicKey":
+		value, resolveErr = s.getAppByPublicKey(args)
+	default:
+		return nil, &Error{Message: fmt.Sprintf("saurontest does not implement %s", field.Name), Code: "NOT_IMPLEMENTED"}
+	}
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	projected, err := s.schema.project(value, def.Type.Name(), field.SelectionSet, fragments)
+	if err != nil {
+		return nil, &Error{Message: err.Error(), Code: "GRAPHQL_VALIDATION_FAILED"}
+	}
+	return projected, nil
+}
+
+var (
+	errDataKeyNotFound = &Error{Message: "data key not found", Code: "NOT_FOUND"}
+	errInvalidArgument = func(name string) *Error {
+		return &Error{Message: fmt.Sprintf("invalid argument %s", name), Code: "BAD_USER_INPUT"}
+	}
+)
+
+func (s *Server) knowsDataKey(dataKey string) bool {
+	_, activities := s.fixtures.Activities[dataKey]
+	_, traits := s.fixtures.Traits[dataKey]
+	return activities || traits
+}
+
+func (s *Server) getActivity(args map[string]interface{}) (interface{}, *Error) {
+	dataKey, _ := args["dataKey"].(string)
+	source, _ := args["source"].(string)
+	limit, ok := toInt(args["limit"])
+	if !ok || limit <= 0 {
+		return nil, errInvalidArgument("limit")
+	}
+	page, ok := toInt(args["page"])
+	if !ok || page <= 0 {
+		return nil, errInvalidArgument("page")
+	}
+	if !s.knowsDataKey(dataKey) {
+		return nil, errDataKeyNotFound
+	}
+	types := toStrings(args["activityType"])
+
+	var matching []interface{}
+	for _, activity := range s.fixtures.Activities[dataKey] {
+		if activity.source() != source {
+			continue
+		}
+		if len(types) > 0 && activity.Type != "" && !contains(types, activity.Type) {
+			continue
+		}
+		matching = append(matching, activity.object())
+	}
+
+	data := []interface{}{}
+	if start := (page - 1) * limit; start < int64(len(matching)) {
+		end := start + limit
+		if end > int64(len(matching)) {
+			end = int64(len(matching))
+		}
+		data = matching[start:end]
+	}
+	return map[string]interface{}{
+		"__typename": "ActivityResponse",
+		"data":       data,
+		"limit":      limit,
+		"total":      len(matching),
+		"page":       page,
+	}, nil
+}
+
+func (s *Server) lookupActivity(args map[string]interface{}) (interface{}, *Error) {
+	dataKey, _ := args["dataKey"].(string)
+	id, _ := args["activityId"].(string)
+	if !s.knowsDataKey(dataKey) {
+		return nil, errDataKeyNotFound
+	}
+	for _, activity := range s.fixtures.Activities[dataKey] {
+		if strings.EqualFold(activity.ID, id) {
+			return activity.object(), nil
+		}
+	}
+	return nil, &Error{Message: "activity not found", Code: "NOT_FOUND"}
+}
+
+func (s *Server) getTraits(args map[string]interface{}) (interface{}, *Error) {
+	dataKey, _ := args["dataKey"].(string)
+	source, _ := args["source"].(string)
+	labels := toStrings(args["labels"])
+	if !s.knowsDataKey(dataKey) {
+		return nil, errDataKeyNotFound
+	}
+
+	traits := []interface{}{}
+	for _, trait := range s.fixtures.Traits[dataKey] {
+		if trait.Source != source || len(labels) > 0 && !contains(labels, trait.Label) {
+			continue
+		}
+		traits = append(traits, trait.object())
+	}
+	return traits, nil
+}
+
+func (s *Server) lookupTrait(args map[string]interface{}) (interface{}, *Error) {
+	dataKey, _ := args["dataKey"].(string)
+	id, _ := args["traitId"].(string)
+	if !s.knowsDataKey(dataKey) {
+		return nil, errDataKeyNotFound
+	}
+	for _, trait := range s.fixtures.Traits[dataKey] {
+		if strings.EqualFold(trait.ID, id) {
+			return trait.object(), nil
+		}
+	}
+	return nil, &Error{Message: "trait not found", Code: "NOT_FOUND"}
+}
+
+func (s *Server) getAppByPublicKey(args map[string]interface{}) (interface{}, *Error) {
+	publicKey, _ := args["publicKey"].(string)
+	for _, app := range s.fixtures.Apps {
+		if strings.EqualFold(strings.TrimPrefix(app.PublicKey, "0x"), strings.TrimPrefix(publicKey, "0x")) {
+			return app.object(), nil
+		}
+	}
+	return nil, &Error{Message: "application not found", Code: "NOT_FOUND"}
+}
+
+func toInt(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		i, err := value.Int64()
+		return i, err == nil
+	case int64:
+		return value, true
+	case string:
+		// Int64 is a custom scalar, which may be sent as a string.
+		var i int64
+		_, err := fmt.Sscan(value, &i)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var strs []string
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package saurontest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/generated"
+	"github.com/google/uuid"
+)
+
+const dataKey = "MY_DATA_KEY"
+
+var (
+	netflixID   = uuid.MustParse("6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a01")
+	instacartID = uuid.MustParse("6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a02")
+	traitID     = uuid.MustParse("6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a03")
+)
+
+func newTestServer(t *testing.T, opts ...Option) (*Server, *generated.EyeOfSauron) {
+	t.Helper()
+
+	activities := []Activity{
+		{
+			ID:       netflixID.String(),
+			Type:     "WATCH",
+			Metadata: json.RawMessage(`{"__typename":"NetflixActivityMetadata","title":"Arcane","subject":[{"value":"tt11126994","identifierType":"IMDB"}],"date":"01/02/2024","lastPlayedAt":"01/03/2024"}`),
+		},
+		{
+			ID:       instacartID.String(),
+			Type:     "SHOP",
+			Metadata: json.RawMessage(`{"__typename":"InstacartActivityMetadata","retailer":"Costco","totalOrderAmountSpent":"$10.00","dateOrdered":"01/02/2024","dateDelivered":"01/03/2024","statusString":"complete","items":[{"__typename":"InstacartOrderItem","itemID":"1","productName":"Milk","unitPrice":"$2.00","status":"FOUND","quantityPurchased":2}]}`),
+		},
+	}
+	for i := 0; i < 5; i++ {
+		activities = append(activities, Activity{
+			ID:       uuid.NewString(),
+			Type:     "WATCH",
+			Metadata: json.RawMessage(`{"__typename":"NetflixActivityMetadata","title":"Episode","date":"01/02/2024","lastPlayedAt":"01/03/2024"}`),
+		})
+	}
+
+	server := NewServer(&Fixtures{
+		Apps:       []App{TestApp},
+		Activities: map[string][]Activity{dataKey: activities},
+		Traits: map[string][]Trait{dataKey: {
+			{ID: traitID.String(), Source: "NETFLIX", Label: "PLAN", Value: "premium", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			{ID: uuid.NewString(), Source: "INSTAGRAM", Label: "FOLLOWER_COUNT", Value: "42"},
+		}},
+	}, opts...)
+	t.Cleanup(server.Close)
+
+	eye, err := generated.NewEyeOfSauron(PrivateKey,
+		generated.WithEndpoint(server.URL),
+		generated.WithRetryPolicy(generated.RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	return server, eye
+}
+
+func TestTestAppPublicKey(t *testing.T) {
+	eye, err := generated.NewEyeOfSauron(PrivateKey)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	if publicKey, _ := eye.PublicKey(); publicKey != TestApp.PublicKey {
+		t.Errorf("public key of PrivateKey = %s, want %s", publicKey, TestApp.PublicKey)
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	_, eye := newTestServer(t)
+
+	resp, err := eye.GetActivity(context.Background(), dataKey, []generated.ActivityType{generated.ActivityTypeWatch}, generated.SourceNetflix, 4, 2)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	activities := resp.GetGetActivity()
+	if activities.Total != 6 || activities.Page != 2 || activities.Limit != 4 || len(activities.Data) != 2 {
+		t.Errorf("GetActivity() = total %d, page %d, limit %d, %d activities, want 6, 2, 4, 2",
+			activities.Total, activities.Page, activities.Limit, len(activities.Data))
+	}
+
+	var titles []string
+	err = eye.EachActivity(context.Background(), dataKey, generated.SourceNetflix, func(activity generated.Activity) error {
+		titles = append(titles, activity.ToActivity().Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachActivity() error = %v", err)
+	}
+	if len(titles) != 6 || titles[0] != "Arcane" {
+		t.Errorf("EachActivity() titles = %v, want Arcane and 5 episodes", titles)
+	}
+}
+
+func TestLookupActivity(t *testing.T) {
+	_, eye := newTestServer(t)
+
+	resp, err := eye.LookupActivity(context.Background(), dataKey, instacartID)
+	if err != nil {
+		t.Fatalf("LookupActivity() error = %v", err)
+	}
+	metadata, ok := resp.LookupActivity.Metadata.(*generated.LookupActivityMetadataInstacartActivityMetadata)
+	if !ok {
+		t.Fatalf("LookupActivity() metadata = %T, want Instacart", resp.LookupActivity.Metadata)
+	}
+	// The items are selected under an alias, InstacartActivityMetadataItems.
+	if items := metadata.InstacartActivityMetadataItems; len(items) != 1 || items[0].ProductName != "Milk" {
+		t.Errorf("LookupActivity() items = %+v, want Milk", items)
+	}
+
+	_, err = eye.LookupActivity(context.Background(), dataKey, uuid.New())
+	var graphqlErrs graphql.GraphQLErrors
+	if !errors.As(err, &graphqlErrs) || graphqlErrs[0].Code() != "NOT_FOUND" {
+		t.Errorf("LookupActivity() error = %v, want NOT_FOUND", err)
+	}
+}
+
+func TestTraits(t *testing.T) {
+	_, eye := newTestServer(t)
+
+	resp, err := eye.GetTraits(context.Background(), dataKey, generated.SourceNetflix, []generated.TraitLabel{generated.TraitLabelPlan})
+	if err != nil {
+		t.Fatalf("GetTraits() error = %v", err)
+	}
+	if traits := resp.GetGetTraits(); len(traits) != 1 || traits[0].Value != "premium" {
+		t.Errorf("GetTraits() = %+v, want the PLAN trait", traits)
+	}
+
+	trait, err := eye.LookupTrait(context.Background(), dataKey, traitID)
+	if err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if trait.LookupTrait.Label != generated.TraitLabelPlan || !trait.LookupTrait.Timestamp.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("LookupTrait() = %+v", trait.LookupTrait)
+	}
+
+	if _, err := eye.GetTraits(context.Background(), "UNKNOWN", generated.SourceNetflix, nil); err == nil {
+		t.Error("GetTraits() expected an error for an unknown data key")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	server, eye := newTestServer(t)
+
+	app, err := eye.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if app.AppName != TestApp.AppName {
+		t.Errorf("Verify() = %+v, want %+v", app, TestApp)
+	}
+
+	stranger, err := generated.NewEyeOfSauron("0x5c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", generated.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	if _, err := stranger.Verify(context.Background()); !errors.Is(err, generated.ErrApplicationNotFound) {
+		t.Errorf("Verify() error = %v, want %v", err, generated.ErrApplicationNotFound)
+	}
+}
+
+func TestSignatureVerification(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	stranger, err := generated.NewEyeOfSauron("0x5c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+		generated.WithEndpoint(server.URL),
+		generated.WithRetryPolicy(generated.RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	_, err = stranger.GetTraits(context.Background(), dataKey, generated.SourceNetflix, nil)
+	var statusErr *graphql.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GetTraits() error = %v, want status 401", err)
+	}
+
+	requests := server.Requests()
+	if last := requests[len(requests)-1]; last.PublicKey != "" || last.Fields[0] != "getTraits" {
+		t.Errorf("last request = %+v, want an unsigned getTraits", last)
+	}
+
+	unverified, _ := newTestServer(t, WithoutSignatureVerification())
+	stranger, _ = generated.NewEyeOfSauron("0x5c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", generated.WithEndpoint(unverified.URL))
+	if _, err := stranger.GetTraits(context.Background(), dataKey, generated.SourceNetflix, nil); err != nil {
+		t.Errorf("GetTraits() error = %v without signature verification", err)
+	}
+}
+
+func TestInject(t *testing.T) {
+	server, eye := newTestServer(t)
+
+	server.Inject("getTraits", Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	server.Inject("getTraits", Fault{Errors: []Error{{Message: "boom", Code: "INTERNAL"}}, Times: 1})
+
+	_, err := eye.GetTraits(context.Background(), dataKey, generated.SourceNetflix, nil)
+	var statusErr *graphql.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("first GetTraits() error = %v, want status 503", err)
+	}
+	_, err = eye.GetTraits(context.Background(), dataKey, generated.SourceNetflix, nil)
+	var graphqlErrs graphql.GraphQLErrors
+	if !errors.As(err, &graphqlErrs) || graphqlErrs[0].Code() != "INTERNAL" {
+		t.Errorf("second GetTraits() error = %v, want INTERNAL", err)
+	}
+	if _, err := eye.GetTraits(context.Background(), dataKey, generated.SourceNetflix, nil); err != nil {
+		t.Errorf("third GetTraits() error = %v", err)
+	}
+
+	// Other fields are left alone, and latency is bounded by the context.
+	server.Inject("", Fault{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := eye.LookupTrait(ctx, dataKey, traitID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LookupTrait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	server.ClearFaults()
+	if _, err := eye.LookupTrait(context.Background(), dataKey, traitID); err != nil {
+		t.Errorf("LookupTrait() error = %v after ClearFaults", err)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	client := graphql.NewClient(server.URL)
+	var resp struct {
+		Schema struct {
+			Types []struct {
+				Kind       string `json:"kind"`
+				Name       string `json:"name"`
+				EnumValues []struct {
+					Name string `json:"name"`
+				} `json:"enumValues"`
+			} `json:"types"`
+		} `json:"__schema"`
+	}
+	req := graphql.NewRequest(`query { __schema { types { kind name enumValues(includeDeprecated: true) { name } } } }`)
+	if err := client.Run(context.Background(), req, &resp); err != nil {
+		t.Fatalf("introspection error = %v", err)
+	}
+
+	var sources []string
+	for _, typ := range resp.Schema.Types {
+		if typ.Name == "Source" {
+			for _, value := range typ.EnumValues {
+				sources = append(sources, value.Name)
+			}
+		}
+	}
+	if !strings.Contains(strings.Join(sources, ","), "NETFLIX") {
+		t.Errorf("introspection Source values = %v, want NETFLIX among them", sources)
+	}
+}
+
+func TestNewServerInvalidFixtures(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewServer() expected a panic for metadata without __typename")
+		}
+	}()
+	NewServer(&Fixtures{Activities: map[string][]Activity{
+		dataKey: {{ID: "1", Metadata: json.RawMessage(`{"title":"Arcane"}`)}},
+	}})
+}
@@ -0,0 +1,275 @@
+package saurontest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// sauronSchema is the schema served unless Fixtures.Schema is given.
+//
+//go:embed schema.graphql
+var sauronSchema string
+
+// schema is a parsed schema. The schemas of Sauron declare the built-in
+// scalars, so they are parsed as documents rather than loaded and validated.
+type schema struct {
+	types map[string]*ast.Definition
+	// order lists the type names in order of declaration.
+	order []string
+	// possibleTypes holds the object types of each interface and union.
+	possibleTypes map[string][]string
+}
+
+func parseSchema(sdl string) (*schema, error) {
+	doc, err := parser.ParseSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	s := &schema{
+		types:         make(map[string]*ast.Definition),
+		possibleTypes: make(map[string][]string),
+	}
+	for _, def := range doc.Definitions {
+		s.types[def.Name] = def
+		s.order = append(s.order, def.Name)
+		for _, iface := range def.Interfaces {
+			s.possibleTypes[iface] = append(s.possibleTypes[iface], def.Name)
+		}
+		if def.Kind == ast.Union {
+			s.possibleTypes[def.Name] = append(s.possibleTypes[def.Name], def.Types...)
+		}
+	}
+	if s.types["Query"] == nil {
+		return nil, fmt.Errorf("invalid schema: no Query type")
+	}
+	return s, nil
+}
+
+// matches reports whether an object of type typename satisfies the type
+// condition of a fragment.
+func (s *schema) matches(condition, typename string) bool {
+	if condition == "" || condition == typename {
+		return true
+	}
+	for _, possible := range s.possibleTypes[condition] {
+		if possible == typename {
+			return true
+		}
+	}
+	return false
+}
+
+// project shapes value, of the named type, after the selection set of a
+// query: fields are renamed to their aliases, fragments are applied to the
+// types they match, and fields that were not selected are dropped. Values of
+// abstract types name their type with __typename. Values of types missing
+// from the schema, such as those of introspection, are shaped by field name
+// alone.
+func (s *schema) project(value interface{}, typename string, selections ast.SelectionSet, fragments ast.FragmentDefinitionList) (interface{}, error) {
+	switch value := value.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			var err error
+			if list[i], err = s.project(item, typename, selections, fragments); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case map[string]interface{}:
+		// Scalars such as JSON and Map hold objects that are not selected.
+		if len(selections) == 0 {
+			return value, nil
+		}
+
+		def := s.types[typename]
+		if def != nil && (def.Kind == ast.Interface || def.Kind == ast.Union) {
+			concrete, _ := value["__typename"].(string)
+			if concrete == "" {
+				return nil, fmt.Errorf("value of abstract type %s has no __typename", typename)
+			}
+			typename, def = concrete, s.types[concrete]
+		}
+		if typename == "" {
+			typename, _ = value["__typename"].(string)
+		}
+
+		object := make(map[string]interface{})
+		if err := s.projectFields(object, value, typename, def, selections, fragments); err != nil {
+			return nil, err
+		}
+		return object, nil
+	default:
+		return value, nil
+	}
+}
+
+func (s *schema) projectFields(object, value map[string]interface{}, typename string, def *ast.Definition, selections ast.SelectionSet, fragments ast.FragmentDefinitionList) error {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			key := selection.Alias
+			if key == "" {
+				key = selection.Name
+			}
+			if selection.Name == "__typename" {
+				object[key] = typename
+				continue
+			}
+
+			var fieldType string
+			if def != nil {
+				field := def.Fields.ForName(selection.Name)
+				if field == nil {
+					return fmt.Errorf("cannot query field %q on type %q", selection.Name, typename)
+				}
+				fieldType = field.Type.Name()
+			}
+			projected, err := s.project(value[selection.Name], fieldType, selection.SelectionSet, fragments)
+			if err != nil {
+				return err
+			}
+			object[key] = projected
+		case *ast.InlineFragment:
+			if def == nil || s.matches(selection.TypeCondition, typename) {
+				if err := s.projectFields(object, value, typename, def, selection.SelectionSet, fragments); err != nil {
+					return err
+				}
+			}
+		case *ast.FragmentSpread:
+			fragment := fragments.ForName(selection.Name)
+			if fragment == nil {
+				return fmt.Errorf("unknown fragment %q", selection.Name)
+			}
+			if def == nil || s.matches(fragment.TypeCondition, typename) {
+				if err := s.projectFields(object, value, typename, def, fragment.SelectionSet, fragments); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// introspection returns the __schema object of the schema.
+func (s *schema) introspection() map[string]interface{} {
+	types := make([]interface{}, 0, len(s.order))
+	for _, name := range s.order {
+		def := s.types[name]
+		t := map[string]interface{}{
+			"kind":          string(def.Kind),
+			"name":          def.Name,
+			"description":   def.Description,
+			"fields":        nil,
+			"inputFields":   nil,
+			"interfaces":    nil,
+			"enumValues":    nil,
+			"possibleTypes": nil,
+		}
+
+		switch def.Kind {
+		case ast.Object, ast.Interface:
+			fields := make([]interface{}, 0, len(def.Fields))
+			for _, field := range def.Fields {
+				args := make([]interface{}, 0, len(field.Arguments))
+				for _, arg := range field.Arguments {
+					args = append(args, s.inputValue(arg.Name, arg.Description, arg.Type, arg.DefaultValue))
+				}
+				fields = append(fields, map[string]interface{}{
+					"name":              field.Name,
+					"description":       field.Description,
+					"args":              args,
+					"type":              s.typeRef(field.Type),
+					"isDeprecated":      false,
+					"deprecationReason": nil,
+				})
+			}
+			t["fields"] = fields
+
+			interfaces := make([]interface{}, 0, len(def.Interfaces))
+			for _, iface := range def.Interfaces {
+				interfaces = append(interfaces, map[string]interface{}{"kind": "INTERFACE", "name": iface, "ofType": nil})
+			}
+			t["interfaces"] = interfaces
+		case ast.InputObject:
+			fields := make([]interface{}, 0, len(def.Fields))
+			for _, field := range def.Fields {
+				fields = append(fields, s.inputValue(field.Name, field.Description, field.Type, field.DefaultValue))
+			}
+			t["inputFields"] = fields
+		case ast.Enum:
+			values := make([]interface{}, 0, len(def.EnumValues))
+			for _, value := range def.EnumValues {
+				values = append(values, map[string]interface{}{
+					"name":              value.Name,
+					"description":       value.Description,
+					"isDeprecated":      false,
+					"deprecationReason": nil,
+				})
+			}
+			t["enumValues"] = values
+		}
+
+		if def.Kind == ast.Interface || def.Kind == ast.Union {
+			possibleTypes := make([]interface{}, 0, len(s.possibleTypes[name]))
+			for _, possible := range s.possibleTypes[name] {
+				possibleTypes = append(possibleTypes, map[string]interface{}{"kind": "OBJECT", "name": possible, "ofType": nil})
+			}
+			t["possibleTypes"] = possibleTypes
+		}
+		types = append(types, t)
+	}
+
+	return map[string]interface{}{
+		"queryType":        map[string]interface{}{"name": "Query"},
+		"mutationType":     nil,
+		"subscriptionType": nil,
+		"types":            types,
+		"directives":       []interface{}{},
+	}
+}
+
+func (s *schema) inputValue(name, description string, typ *ast.Type, defaultValue *ast.Value) map[string]interface{} {
+	var value interface{}
+	if defaultValue != nil {
+		value = defaultValue.String()
+	}
+	return map[string]interface{}{
+		"name":         name,
+		"description":  description,
+		"type":         s.typeRef(typ),
+		"defaultValue": value,
+	}
+}
+
+// typeRef returns the introspection reference to a type, wrapped in
+// NON_NULL and LIST as declared.
+func (s *schema) typeRef(t *ast.Type) map[string]interface{} {
+	var ref map[string]interface{}
+	if t.Elem != nil {
+		ref = map[string]interface{}{"kind": "LIST", "name": nil, "ofType": s.typeRef(t.Elem)}
+	} else {
+		kind := string(ast.Scalar)
+		if def := s.types[t.NamedType]; def != nil {
+			kind = string(def.Kind)
+		}
+		ref = map[string]interface{}{"kind": kind, "name": t.NamedType, "ofType": nil}
+	}
+	if t.NonNull {
+		return map[string]interface{}{"kind": "NON_NULL", "name": nil, "ofType": ref}
+	}
+	return ref
+}
+
+// decodeJSON decodes data into v, keeping numbers exact.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,145 @@
+// Package signing verifies the X-Gandalf-Signature header of requests made by
+// the generated EyeOfSauron client, as Sauron does. It is meant for proxies
+// and test doubles standing in for Sauron.
+//
+// A request is signed with the secp256k1 private key of an application: the
+// header holds the base64-encoded ASN.1 ECDSA signature of the SHA-256 hash
+// of the request body.
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Header is the request header carrying the signature.
+const Header = "X-Gandalf-Signature"
+
+// maxBodyBytes bounds the request bodies read by Middleware.
+const maxBodyBytes = 10 << 20
+
+// ErrInvalidSignature is returned when a signature does not match the body
+// and public key.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// VerifySignature checks that sigB64 is the signature of body made with the
+// private key of pubKey, a compressed or uncompressed secp256k1 public key.
+// It mirrors SignMessageAsBase64 of the generated client.
+func VerifySignature(pubKey, body []byte, sigB64 string) error {
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	return verify(key.ToECDSA(), body, sigB64)
+}
+
+func verify(key *ecdsa.PublicKey, body []byte, sigB64 string) error {
+	if sigB64 == "" {
+		return fmt.Errorf("%w: missing", ErrInvalidSignature)
+	}
+	signature, err := base64.StdEncoding.DecodeString(sigB64)
+	if err != nil {
+		return fmt.Errorf("%w: not base64: %v", ErrInvalidSignature, err)
+	}
+
+	hash := sha256.Sum256(body)
+	if !ecdsa.VerifyASN1(key, hash[:], signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Verifier verifies signatures against a set of allowed public keys.
+type Verifier struct {
+	keys []allowedKey
+}
+
+type allowedKey struct {
+	hex string
+	key *ecdsa.PublicKey
+}
+
+// NewVerifier returns a Verifier accepting signatures made with any of the
+// public keys, given in the hexadecimal form of connect.Config.PublicKey.
+func NewVerifier(publicKeys ...string) (*Verifier, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("at least one public key is required")
+	}
+
+	v := &Verifier{}
+	for _, publicKey := range publicKeys {
+		keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %w", publicKey, err)
+		}
+		key, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %w", publicKey, err)
+		}
+		v.keys = append(v.keys, allowedKey{
+			hex: "0x" + hex.EncodeToString(key.SerializeCompressed()),
+			key: key.ToECDSA(),
+		})
+	}
+	return v, nil
+}
+
+// Verify checks that sigB64 is the signature of body made with one of the
+// allowed keys, and returns that key in compressed hexadecimal form.
+func (v *Verifier) Verify(body []byte, sigB64 string) (string, error) {
+	var err error
+	for _, allowed := range v.keys {
+		if err = verify(allowed.key, body, sigB64); err == nil {
+			return allowed.hex, nil
+		}
+	}
+	return "", err
+}
+
+type contextKey struct{}
+
+// PublicKeyFromContext returns the public key that signed the request, in
+// compressed hexadecimal form, within handlers wrapped by Middleware.
+func PublicKeyFromContext(ctx context.Context) (string, bool) {
+	publicKey, ok := ctx.Value(contextKey{}).(string)
+	return publicKey, ok
+}
+
+// Middleware verifies the signature of every request before passing it on
+// to next, with its body intact and the signing key available from
+// PublicKeyFromContext. Requests that are not signed with an allowed key are
+// answered with 401 Unauthorized.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "could not read request body", http.StatusBadRequest)
+			return
+		}
+
+		publicKey, err := v.Verify(body, r.Header.Get(Header))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, publicKey)))
+	})
+}
@@ -0,0 +1,127 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// sign signs body as SignMessageAsBase64 of the generated client does.
+func sign(t *testing.T, key *btcec.PrivateKey, body []byte) string {
+	t.Helper()
+	hash := sha256.Sum256(body)
+	signature, err := ecdsa.SignASN1(rand.Reader, key.ToECDSA(), hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func newKey(t *testing.T) (*btcec.PrivateKey, string) {
+	t.Helper()
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, "0x" + hex.EncodeToString(key.PubKey().SerializeCompressed())
+}
+
+func TestVerifySignature(t *testing.T) {
+	key, _ := newKey(t)
+	other, _ := newKey(t)
+	body := []byte(`{"query":"query getTraits { getTraits { id } }","variables":{}}` + "\n")
+	signature := sign(t, key, body)
+
+	tests := []struct {
+		name      string
+		pubKey    []byte
+		body      []byte
+		signature string
+		expected  error
+	}{
+		{name: "compressed key", pubKey: key.PubKey().SerializeCompressed(), body: body, signature: signature},
+		{name: "uncompressed key", pubKey: key.PubKey().SerializeUncompressed(), body: body, signature: signature},
+		{name: "other key", pubKey: other.PubKey().SerializeCompressed(), body: body, signature: signature, expected: ErrInvalidSignature},
+		{name: "tampered body", pubKey: key.PubKey().SerializeCompressed(), body: append([]byte(" "), body...), signature: signature, expected: ErrInvalidSignature},
+		{name: "missing signature", pubKey: key.PubKey().SerializeCompressed(), body: body, expected: ErrInvalidSignature},
+		{name: "not base64", pubKey: key.PubKey().SerializeCompressed(), body: body, signature: "%%%", expected: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySignature(tt.pubKey, tt.body, tt.signature); !errors.Is(err, tt.expected) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+
+	if err := VerifySignature([]byte{0x02, 0x01}, body, signature); err == nil {
+		t.Error("VerifySignature() expected an error for an invalid public key")
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	if _, err := NewVerifier(); err == nil {
+		t.Error("NewVerifier() expected an error without keys")
+	}
+	if _, err := NewVerifier("0x02ab"); err == nil {
+		t.Error("NewVerifier() expected an error for an invalid key")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	key, publicKey := newKey(t)
+	other, otherPublicKey := newKey(t)
+	stranger, _ := newKey(t)
+
+	verifier, err := NewVerifier(otherPublicKey, strings.TrimPrefix(publicKey, "0x"))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	handler := verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signer, _ := PublicKeyFromContext(r.Context())
+		io.WriteString(w, signer+" "+string(body))
+	}))
+
+	body := `{"query":"query q { q }"}`
+	tests := []struct {
+		name           string
+		key            *btcec.PrivateKey
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "first key", key: other, expectedStatus: http.StatusOK, expectedBody: otherPublicKey + " " + body},
+		{name: "second key", key: key, expectedStatus: http.StatusOK, expectedBody: publicKey + " " + body},
+		{name: "unknown key", key: stranger, expectedStatus: http.StatusUnauthorized},
+		{name: "unsigned", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/public/gql", strings.NewReader(body))
+			if tt.key != nil {
+				req.Header.Set(Header, sign(t, tt.key, []byte(body)))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedBody != "" && rec.Body.String() != tt.expectedBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// templateData is passed to the client templates.
+type templateData struct {
+	Package string
+	// Queries holds the names of the queries of the schema, so that
+	// templates can skip helpers for queries that do not exist.
+	Queries map[string]bool
+	// ActivityMetadata describes every implementation of the
+	// ActivityMetadata interface, sorted by name.
+	ActivityMetadata []activityMetadata
+	// MoneyFields lists the price and cost fields of the generated structs.
+	MoneyFields []moneyField
+	// TraitLabels lists the values of the TraitLabel enum with their kind.
+	TraitLabels []traitLabel
+	// TraitTypes lists the generated structs holding a trait label and value.
+	TraitTypes []string
+	// Tracing adds WithTracerProvider to the client, which then depends on
+	// OpenTelemetry.
+	Tracing bool
+}
+
+// traitLabel is a TraitLabel enum value and the kind of the values of traits
+// with that label, with the names of their generated constants, such as
+// TraitLabelFollowerCount and TraitKindInt.
+type traitLabel struct {
+	Name      string
+	Kind      string
+	Const     string
+	KindConst string
+}
+
+// traitKinds is the kind of the values of each TraitLabel, which Sauron
+// always returns as strings. Labels missing from the table are strings.
+var traitKinds = map[string]string{
+	"PRIME_SUBSCRIBER":   "BOOL",
+	"RATING":             "FLOAT",
+	"TRIP_COUNT":         "INT",
+	"ACCOUNT_CREATED_ON": "TIME",
+	"PLAN":               "STRING",
+	"GENIUS_LEVEL":       "STRING",
+	"FOLLOWER_COUNT":     "INT",
+	"FOLLOWING_COUNT":    "INT",
+	"USERNAME":           "STRING",
+	"POST_COUNT":         "INT",
+	"EMAIL":              "EMAIL",
+	"ORDER_COUNT":        "INT",
+}
+
+// moneyField is a price or cost field of a generated struct, exposed as a
+// graphqlTypes.Money by a generated accessor.
+type moneyField struct {
+	Type  string
+	Field string
+	// Float is set when the field is a float64 rather than a string.
+	Float bool
+	// Currency is the sibling field holding the currency, if any.
+	Currency string
+}
+
+// moneyFieldNames are the GraphQL names of the fields holding an amount of money.
+var moneyFieldNames = map[string]bool{
+	"price":                 true,
+	"unitPrice":             true,
+	"cost":                  true,
+	"totalCost":             true,
+	"totalPrice":            true,
+	"totalOrderAmountSpent": true,
+}
+
+// activityMetadata describes how an implementation of ActivityMetadata maps
+// onto NormalizedActivity. Field names are those of the generated Go
+// struct, and are empty when the implementation has no matching field.
+type activityMetadata struct {
+	// Name is the GraphQL type, and of the fragment struct generated for it.
+	Name string
+	// Variant is Name without the ActivityMetadata suffix, such as Netflix.
+	Variant string
+	// Source and Kind are the constants of the Source and ActivityType enum
+	// values, such as SourceNetflix and ActivityTypeWatch.
+	Source string
+	Kind   string
+
+	Title      string
+	OccurredAt string
+	// OccurredAtDate is set when OccurredAt is a Date rather than a Time.
+	OccurredAtDate bool
+	Amount         string
+	// AmountFloat is set when Amount is a Float rather than a String.
+	AmountFloat bool
+	Currency    string
+	Subject     string
+}
+
+// Fields mapped onto NormalizedActivity, in order of preference.
+var (
+	activityTitleFields      = []string{"title", "productName", "restaurant", "retailer"}
+	activityOccurredAtFields = []string{"date", "beginTripTime", "dateOrdered", "lastPlayedAt"}
+	activityAmountFields     = []string{"totalCost", "totalPrice", "totalOrderAmountSpent", "price", "cost"}
+	activityCurrencyFields   = []string{"currency"}
+	activitySubjectFields    = []string{"subject"}
+)
+
+// activityKinds is the ActivityType of the activities of each source. Sources
+// covering several kinds, such as BOOKING, are left out.
+var activityKinds = map[string]string{
+	"NETFLIX":     "WATCH",
+	"YOUTUBE":     "WATCH",
+	"PLAYSTATION": "PLAY",
+	"AMAZON":      "SHOP",
+	"INSTACART":   "SHOP",
+	"UBEREATS":    "SHOP",
+	"UBER":        "TRIP",
+}
+
+func buildTemplateData(pkg string, introspection IntrospectionResult) templateData {
+	data := templateData{
+		Package: pkg,
+		Queries: make(map[string]bool),
+	}
+
+	typesMap := buildtypesMap(introspection)
+	sources := make(map[string]bool)
+	for _, value := range typesMap["Source"].EnumValues {
+		sources[value.Name] = true
+	}
+
+	for _, t := range introspection.Schema.Types {
+		if t.Kind == "OBJECT" && t.Name == "Query" {
+			for _, field := range t.Fields {
+				data.Queries[field.Name] = true
+			}
+		}
+	}
+
+	for _, name := range buildInterfaceImplementationsMap(introspection)["ActivityMetadata"] {
+		data.ActivityMetadata = append(data.ActivityMetadata, buildActivityMetadata(typesMap[name], sources))
+	}
+	sort.Slice(data.ActivityMetadata, func(i, j int) bool {
+		return data.ActivityMetadata[i].Name < data.ActivityMetadata[j].Name
+	})
+
+	for _, value := range typesMap["TraitLabel"].EnumValues {
+		kind, ok := traitKinds[value.Name]
+		if !ok {
+			kind = "STRING"
+		}
+		data.TraitLabels = append(data.TraitLabels, traitLabel{
+			Name:      value.Name,
+			Kind:      kind,
+			Const:     "TraitLabel" + goConstName(value.Name),
+			KindConst: "TraitKind" + goConstName(kind),
+		})
+	}
+	return data
+}
+
+// goConstName converts an enum value such as FOLLOWER_COUNT into the suffix
+// genqlient gives its constant, such as FollowerCount.
+func goConstName(s string) string {
+	if strings.TrimLeft(s, "_") == "" {
+		return s
+	}
+	var prev rune
+	return strings.Map(func(r rune) rune {
+		var ret rune
+		if r == '_' {
+			ret = -1
+		} else if prev == '_' || prev == 0 {
+			ret = unicode.ToUpper(r)
+		} else {
+			ret = unicode.ToLower(r)
+		}
+		prev = r
+		return ret
+	}, s)
+}
+
+func buildActivityMetadata(t Type, sources map[string]bool) activityMetadata {
+	metadata := activityMetadata{
+		Name:    t.Name,
+		Variant: upperFirst(strings.TrimSuffix(t.Name, "ActivityMetadata")),
+	}
+
+	source := strings.ToUpper(metadata.Variant)
+	if sources[source] {
+		metadata.Source = "Source" + goConstName(source)
+		if kind, ok := activityKinds[source]; ok {
+			metadata.Kind = "ActivityType" + goConstName(kind)
+		}
+	}
+
+	var scalar string
+	metadata.Title, _ = findActivityField(t, activityTitleFields, "String")
+	metadata.OccurredAt, scalar = findActivityField(t, activityOccurredAtFields, "Date", "Time")
+	metadata.OccurredAtDate = scalar == "Date"
+	metadata.Amount, scalar = findActivityField(t, activityAmountFields, "String", "Float")
+	metadata.AmountFloat = scalar == "Float"
+	metadata.Currency, _ = findActivityField(t, activityCurrencyFields, "String")
+	metadata.Subject, _ = findActivityField(t, activitySubjectFields, "Identifier")
+	return metadata
+}
+
+// findActivityField returns the Go name and scalar of the first of the
+// candidate fields that t has with one of the given types.
+func findActivityField(t Type, candidates []string, types ...string) (string, string) {
+	for _, candidate := range candidates {
+		for _, field := range t.Fields {
+			if field.Name != candidate {
+				continue
+			}
+			name := findInnermostType(&field.Type).Name
+			for _, typ := range types {
+				if name == typ {
+					return upperFirst(field.Name), name
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// findMoneyFields finds the fields listed in moneyFieldNames in the exported
+// structs of the code generated by genqlient, using their JSON tags.
+func findMoneyFields(src []byte) ([]moneyField, error) {
+	structs, err := parseGeneratedStructs(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []moneyField
+	for _, s := range structs {
+		var currency string
+		var found []moneyField
+		for _, field := range s.fields {
+			switch {
+			case field.jsonName == "currency" && field.typ == "string":
+				currency = field.name
+			case moneyFieldNames[field.jsonName] && (field.typ == "string" || field.typ == "float64"):
+				found = append(found, moneyField{
+					Type:  s.name,
+					Field: field.name,
+					Float: field.typ == "float64",
+				})
+			}
+		}
+		for i := range found {
+			found[i].Currency = currency
+		}
+		fields = append(fields, found...)
+	}
+	return fields, nil
+}
+
+// findTraitTypes returns the generated structs holding a trait, which have a
+// label of type TraitLabel and a string value.
+func findTraitTypes(src []byte) ([]string, error) {
+	structs, err := parseGeneratedStructs(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []string
+	for _, s := range structs {
+		var label, value bool
+		for _, field := range s.fields {
+			switch {
+			case field.name == "Label" && field.jsonName == "label" && field.typ == "TraitLabel":
+				label = true
+			case field.name == "Value" && field.jsonName == "value" && field.typ == "string":
+				value = true
+			}
+		}
+		if label && value {
+			types = append(types, s.name)
+		}
+	}
+	return types, nil
+}
+
+// generatedStruct is an exported struct of the generated code, with its
+// named fields whose type is an identifier, such as string or TraitLabel.
+type generatedStruct struct {
+	name   string
+	fields []generatedField
+}
+
+type generatedField struct {
+	name     string
+	jsonName string
+	typ      string
+}
+
+// parseGeneratedStructs returns the exported structs of the generated code,
+// in order of declaration.
+func parseGeneratedStructs(src []byte) ([]generatedStruct, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "generated.go", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse generated code: %w", err)
+	}
+
+	var structs []generatedStruct
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok || !typeSpec.Name.IsExported() {
+				continue
+			}
+
+			s := generatedStruct{name: typeSpec.Name.Name}
+			for _, field := range structType.Fields.List {
+				if len(field.Names) != 1 || field.Tag == nil {
+					continue
+				}
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					continue
+				}
+				ident, ok := field.Type.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+				s.fields = append(s.fields, generatedField{
+					name:     field.Names[0].Name,
+					jsonName: name,
+					typ:      ident.Name,
+				})
+			}
+			structs = append(structs, s)
+		}
+	}
+	return structs, nil
+}
+
+// renderTemplates renders every client template into a Go source file named
+// after the template, keyed by file name. Templates that render to nothing,
+// because the schema lacks what they need, are skipped.
+func renderTemplates(data templateData) (map[string][]byte, error) {
+	names, err := fs.Glob(templates, "templates/*.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		tmpl, err := template.ParseFS(templates, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse template %s: %w", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("could not render template %s: %w", name, err)
+		}
+
+		if strings.TrimSpace(buf.String()) == "" {
+			continue
+		}
+
+		filename := strings.TrimSuffix(strings.TrimPrefix(name, "templates/"), ".tmpl")
+		content, err := imports.Process(filename, buf.Bytes(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not format %s: %w", filename, err)
+		}
+		files[filename] = content
+	}
+	return files, nil
+}
+
+// operationSigning matches the code genqlient writes in every operation to
+// sign the request body before running it.
+var operationSigning = regexp.MustCompile(`(?s)\n\trequestBodyObj := struct \{.*?\treq\.Header\.Set\("X-Gandalf-Signature", signatureB64\)\n`)
+
+// rewriteGenerated removes the client that genqlient writes at the top of the
+// generated file, which is replaced by the one rendered from client.go.tmpl.
+// Operations are rewritten to run through EyeOfSauron.run, which signs every
+// attempt of a request, and to return their errors through partialResponse.
+func rewriteGenerated(src []byte) ([]byte, error) {
+	content, err := removeGeneratedClient(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = rewriteOperations(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return imports.Process("generated.go", []byte(content), nil)
+}
+
+func removeGeneratedClient(content string) (string, error) {
+	start := strings.Index(content, "type EyeOfSauron struct {")
+	if start < 0 {
+		return "", fmt.Errorf("could not find the EyeOfSauron client in the generated code")
+	}
+	signer := strings.Index(content[start:], "func SignMessageAsBase64(")
+	if signer < 0 {
+		return "", fmt.Errorf("could not find SignMessageAsBase64 in the generated code")
+	}
+	end := strings.Index(content[start+signer:], "\n}\n")
+	if end < 0 {
+		return "", fmt.Errorf("could not find the end of SignMessageAsBase64 in the generated code")
+	}
+	return content[:start] + content[start+signer+end+len("\n}\n"):], nil
+}
+
+// operationError is the statement genqlient writes in every operation to
+// return the error of the request.
+const operationError = `return nil, fmt.Errorf("failed to execute request: %v", err_)`
+
+func rewriteOperations(content string) (string, error) {
+	operations := strings.Count(content, "_Operation = `")
+	if signing := len(operationSigning.FindAllStringIndex(content, -1)); signing != operations {
+		return "", fmt.Errorf("found request signing in %d of %d operations", signing, operations)
+	}
+	if runs := strings.Count(content, "eye.client.Run("); runs != operations {
+		return "", fmt.Errorf("found eye.client.Run in %d of %d operations", runs, operations)
+	}
+	if returns := strings.Count(content, operationError); returns != operations {
+		return "", fmt.Errorf("found the request error return in %d of %d operations", returns, operations)
+	}
+
+	content = operationSigning.ReplaceAllString(content, "")
+	content = strings.ReplaceAll(content, "eye.client.Run(", "eye.run(")
+	return strings.ReplaceAll(content, operationError, "return partialResponse(eye, &resp_, err_)"), nil
+}
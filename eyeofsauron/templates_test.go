@@ -0,0 +1,490 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	gqlparser "github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	files, err := renderTemplates(templateData{Package: "sauron"})
+	if err != nil {
+		t.Fatalf("renderTemplates() error = %v", err)
+	}
+
+	content, ok := files["client.go"]
+	if !ok {
+		t.Fatal("renderTemplates() did not render client.go")
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "client.go", content, 0)
+	if err != nil {
+		t.Fatalf("client.go is not valid Go: %v", err)
+	}
+	if file.Name.Name != "sauron" {
+		t.Errorf("client.go package = %s, want sauron", file.Name.Name)
+	}
+	if !strings.Contains(string(content), "func NewEyeOfSauron(privateKey string, opts ...Option) (*EyeOfSauron, error)") {
+		t.Error("client.go does not declare NewEyeOfSauron with options")
+	}
+
+	if signer, ok := files["signer.go"]; !ok || !strings.Contains(string(signer), "func WithSigner(signer Signer) Option") {
+		t.Error("renderTemplates() did not render WithSigner in signer.go")
+	}
+}
+
+func TestRenderTemplatesTracing(t *testing.T) {
+	tests := []struct {
+		name    string
+		tracing bool
+	}{
+		{name: "with tracing", tracing: true},
+		{name: "without tracing", tracing: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := renderTemplates(templateData{Package: "sauron", Tracing: tt.tracing})
+			if err != nil {
+				t.Fatalf("renderTemplates() error = %v", err)
+			}
+			if _, ok := files["tracing.go"]; ok != tt.tracing {
+				t.Errorf("renderTemplates() rendered tracing.go = %v, want %v", ok, tt.tracing)
+			}
+			if got := strings.Contains(string(files["client.go"]), "otelgraphql"); got != tt.tracing {
+				t.Errorf("client.go imports otelgraphql = %v, want %v", got, tt.tracing)
+			}
+			if got := strings.Contains(string(files["retry.go"]), "otelgraphql.RecordRetry"); got != tt.tracing {
+				t.Errorf("retry.go records retries = %v, want %v", got, tt.tracing)
+			}
+			if got := strings.Contains(string(files["cache.go"]), "func (eye EyeOfSauron) runCached("); got != tt.tracing {
+				t.Errorf("cache.go wraps runCached = %v, want %v", got, tt.tracing)
+			}
+		})
+	}
+}
+
+func TestRenderTemplatesSkipsMissingQueries(t *testing.T) {
+	tests := []struct {
+		name     string
+		queries  map[string]bool
+		filename string
+		expected bool
+	}{
+		{
+			name:     "schema with getActivity",
+			queries:  map[string]bool{"getActivity": true},
+			filename: "activities.go",
+			expected: true,
+		},
+		{
+			name:     "schema without getActivity",
+			queries:  map[string]bool{"getTraits": true},
+			filename: "activities.go",
+			expected: false,
+		},
+		{
+			name:     "schema without getActivity export",
+			queries:  map[string]bool{"lookupActivity": true},
+			filename: "export.go",
+			expected: false,
+		},
+		{
+			name:     "schema without traits",
+			queries:  map[string]bool{"getActivity": true},
+			filename: "traits.go",
+			expected: false,
+		},
+		{
+			name:     "schema with getAppByPublicKey",
+			queries:  map[string]bool{"getAppByPublicKey": true},
+			filename: "verify.go",
+			expected: true,
+		},
+		{
+			name:     "schema without getAppByPublicKey",
+			queries:  map[string]bool{"getTraits": true},
+			filename: "verify.go",
+			expected: false,
+		},
+		{
+			name:     "schema with lookupActivity",
+			queries:  map[string]bool{"lookupActivity": true},
+			filename: "activity.go",
+			expected: true,
+		},
+		{
+			name:     "schema without activities",
+			queries:  map[string]bool{"getTraits": true},
+			filename: "activity.go",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := renderTemplates(templateData{Package: "sauron", Queries: tt.queries})
+			if err != nil {
+				t.Fatalf("renderTemplates() error = %v", err)
+			}
+			if _, ok := files[tt.filename]; ok != tt.expected {
+				t.Errorf("renderTemplates() rendered %s = %v, want %v", tt.filename, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteGenerated(t *testing.T) {
+	src := `// Code generated by github.com/gandalf-network/gandalf-sdk-go/eyeofsauron, DO NOT EDIT.
+
+package generated
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+)
+
+type EyeOfSauron struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func NewEyeOfSauron(privateKey string) (*EyeOfSauron, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// SignMessage signs a message using the given ECDSA private key.
+func SignMessageAsBase64(privKey *ecdsa.PrivateKey, message []byte) (string, error) {
+	return "", nil
+}
+
+type ActivityType string
+
+func (v ActivityType) String() string { return fmt.Sprint(string(v)) }
+`
+	expected := `// Code generated by github.com/gandalf-network/gandalf-sdk-go/eyeofsauron, DO NOT EDIT.
+
+package generated
+
+import (
+	"fmt"
+)
+
+type ActivityType string
+
+func (v ActivityType) String() string { return fmt.Sprint(string(v)) }
+`
+
+	got, err := rewriteGenerated([]byte(src))
+	if err != nil {
+		t.Fatalf("rewriteGenerated() error = %v", err)
+	}
+	if string(got) != expected {
+		t.Errorf("rewriteGenerated() = %v, want %v", string(got), expected)
+	}
+
+	if _, err := rewriteGenerated([]byte("package generated\n")); err == nil {
+		t.Error("rewriteGenerated() expected an error when the client is missing")
+	}
+}
+
+func TestRewriteOperations(t *testing.T) {
+	src := `func (eye *EyeOfSauron) GetTraits(ctx_ context.Context) (*getTraitsResponse, error) {
+	req := graphql.NewRequest(getTraits_Operation)
+
+	requestBodyObj := struct {
+		Query     string                 ` + "`json:\"query\"`" + `
+		Variables map[string]interface{} ` + "`json:\"variables\"`" + `
+	}{
+		Query:     req.Query(),
+		Variables: req.Vars(),
+	}
+
+	var err_ error
+	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
+	req.Header.Set("X-Gandalf-Signature", signatureB64)
+
+	var resp_ getTraitsResponse
+
+	if err_ := eye.client.Run(
+		ctx_,
+		req,
+		&resp_,
+	); err_ != nil {
+		return nil, fmt.Errorf("failed to execute request: %v", err_)
+	}
+	return &resp_, nil
+}
+
+const getTraits_Operation = ` + "`query getTraits { getTraits { id } }`" + `
+`
+	expected := `func (eye *EyeOfSauron) GetTraits(ctx_ context.Context) (*getTraitsResponse, error) {
+	req := graphql.NewRequest(getTraits_Operation)
+
+	var resp_ getTraitsResponse
+
+	if err_ := eye.run(
+		ctx_,
+		req,
+		&resp_,
+	); err_ != nil {
+		return partialResponse(eye, &resp_, err_)
+	}
+	return &resp_, nil
+}
+
+const getTraits_Operation = ` + "`query getTraits { getTraits { id } }`" + `
+`
+
+	got, err := rewriteOperations(src)
+	if err != nil {
+		t.Fatalf("rewriteOperations() error = %v", err)
+	}
+	if got != expected {
+		t.Errorf("rewriteOperations() = %v, want %v", got, expected)
+	}
+
+	unsigned := strings.Replace(src, `req.Header.Set("X-Gandalf-Signature", signatureB64)`, "", 1)
+	if _, err := rewriteOperations(unsigned); err == nil {
+		t.Error("rewriteOperations() expected an error when an operation is not signed")
+	}
+}
+
+func TestBuildTemplateDataActivityMetadata(t *testing.T) {
+	named := func(name string) Type {
+		return Type{Kind: "NON_NULL", OfType: &Type{Kind: "SCALAR", Name: name}}
+	}
+
+	var introspection IntrospectionResult
+	introspection.Schema.Types = []Type{
+		{Kind: "ENUM", Name: "Source", EnumValues: []Value{{Name: "NETFLIX"}, {Name: "UBEREATS"}}},
+		{Kind: "INTERFACE", Name: "ActivityMetadata", PossibleTypes: []Type{
+			{Name: "UberEatsActivityMetadata"},
+			{Name: "NetflixActivityMetadata"},
+			{Name: "LegacyActivityMetadata"},
+		}},
+		{Kind: "OBJECT", Name: "NetflixActivityMetadata", Fields: []Field{
+			{Name: "title", Type: named("String")},
+			{Name: "subject", Type: Type{Kind: "LIST", OfType: &Type{Kind: "OBJECT", Name: "Identifier"}}},
+			{Name: "lastPlayedAt", Type: named("Date")},
+			{Name: "date", Type: named("Date")},
+		}},
+		{Kind: "OBJECT", Name: "UberEatsActivityMetadata", Fields: []Field{
+			{Name: "restaurant", Type: named("String")},
+			{Name: "date", Type: named("Time")},
+			{Name: "totalPrice", Type: named("Float")},
+			{Name: "currency", Type: named("String")},
+		}},
+		{Kind: "OBJECT", Name: "LegacyActivityMetadata", Fields: []Field{
+			{Name: "title", Type: named("Int")},
+		}},
+	}
+
+	expected := []activityMetadata{
+		{Name: "LegacyActivityMetadata", Variant: "Legacy"},
+		{
+			Name:           "NetflixActivityMetadata",
+			Variant:        "Netflix",
+			Source:         "SourceNetflix",
+			Kind:           "ActivityTypeWatch",
+			Title:          "Title",
+			OccurredAt:     "Date",
+			OccurredAtDate: true,
+			Subject:        "Subject",
+		},
+		{
+			Name:        "UberEatsActivityMetadata",
+			Variant:     "UberEats",
+			Source:      "SourceUbereats",
+			Kind:        "ActivityTypeShop",
+			Title:       "Restaurant",
+			OccurredAt:  "Date",
+			Amount:      "TotalPrice",
+			AmountFloat: true,
+			Currency:    "Currency",
+		},
+	}
+
+	data := buildTemplateData("sauron", introspection)
+	if !reflect.DeepEqual(data.ActivityMetadata, expected) {
+		t.Errorf("buildTemplateData() ActivityMetadata = %+v, want %+v", data.ActivityMetadata, expected)
+	}
+}
+
+func TestFindMoneyFields(t *testing.T) {
+	src := "package generated\n\n" +
+		"type UberEatsActivityMetadata struct {\n" +
+		"\tRestaurant string `json:\"restaurant\"`\n" +
+		"\tTotalPrice float64 `json:\"totalPrice\"`\n" +
+		"\tCurrency string `json:\"currency\"`\n" +
+		"}\n\n" +
+		"type AmazonActivityMetadata struct {\n" +
+		"\tTotalCost string `json:\"totalCost\"`\n" +
+		"\tQuantityPurchased int `json:\"quantityPurchased\"`\n" +
+		"}\n\n" +
+		"type __premarshalAmazonActivityMetadata struct {\n" +
+		"\tTotalCost string `json:\"totalCost\"`\n" +
+		"}\n"
+
+	expected := []moneyField{
+		{Type: "UberEatsActivityMetadata", Field: "TotalPrice", Float: true, Currency: "Currency"},
+		{Type: "AmazonActivityMetadata", Field: "TotalCost"},
+	}
+
+	got, err := findMoneyFields([]byte(src))
+	if err != nil {
+		t.Fatalf("findMoneyFields() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("findMoneyFields() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestBuildTemplateDataTraitLabels(t *testing.T) {
+	var introspection IntrospectionResult
+	introspection.Schema.Types = []Type{
+		{Kind: "ENUM", Name: "TraitLabel", EnumValues: []Value{
+			{Name: "FOLLOWER_COUNT"},
+			{Name: "PRIME_SUBSCRIBER"},
+			{Name: "FAVOURITE_GENRE"},
+		}},
+	}
+
+	expected := []traitLabel{
+		{Name: "FOLLOWER_COUNT", Kind: "INT", Const: "TraitLabelFollowerCount", KindConst: "TraitKindInt"},
+		{Name: "PRIME_SUBSCRIBER", Kind: "BOOL", Const: "TraitLabelPrimeSubscriber", KindConst: "TraitKindBool"},
+		{Name: "FAVOURITE_GENRE", Kind: "STRING", Const: "TraitLabelFavouriteGenre", KindConst: "TraitKindString"},
+	}
+
+	data := buildTemplateData("sauron", introspection)
+	if !reflect.DeepEqual(data.TraitLabels, expected) {
+		t.Errorf("buildTemplateData() TraitLabels = %+v, want %+v", data.TraitLabels, expected)
+	}
+}
+
+func TestFindTraitTypes(t *testing.T) {
+	src := "package generated\n\n" +
+		"type LookupTrait struct {\n" +
+		"\tId string `json:\"id\"`\n" +
+		"\tLabel TraitLabel `json:\"label\"`\n" +
+		"\tValue string `json:\"value\"`\n" +
+		"}\n\n" +
+		"type LookupActivity struct {\n" +
+		"\tId string `json:\"id\"`\n" +
+		"\tValue string `json:\"value\"`\n" +
+		"}\n\n" +
+		"type TraitLabel string\n"
+
+	got, err := findTraitTypes([]byte(src))
+	if err != nil {
+		t.Fatalf("findTraitTypes() error = %v", err)
+	}
+	if expected := []string{"LookupTrait"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("findTraitTypes() = %v, want %v", got, expected)
+	}
+}
+
+// loadSchema converts a schema file into the introspection result Sauron
+// returns for it.
+func loadSchema(t *testing.T, path string) IntrospectionResult {
+	t.Helper()
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, gqlErr := gqlparser.ParseSchema(&ast.Source{Name: path, Input: string(src)})
+	if gqlErr != nil {
+		t.Fatalf("ParseSchema() error = %v", gqlErr)
+	}
+
+	implementations := make(map[string][]string)
+	for _, def := range doc.Definitions {
+		for _, name := range def.Interfaces {
+			implementations[name] = append(implementations[name], def.Name)
+		}
+		if def.Kind == ast.Union {
+			implementations[def.Name] = append(implementations[def.Name], def.Types...)
+		}
+	}
+
+	var introspection IntrospectionResult
+	for _, def := range doc.Definitions {
+		t := Type{Kind: string(def.Kind), Name: def.Name}
+		for _, field := range def.Fields {
+			t.Fields = append(t.Fields, Field{Name: field.Name, Type: schemaType(field.Type)})
+		}
+		for _, value := range def.EnumValues {
+			t.EnumValues = append(t.EnumValues, Value{Name: value.Name})
+		}
+		for _, name := range def.Interfaces {
+			t.Interfaces = append(t.Interfaces, Type{Kind: "INTERFACE", Name: name})
+		}
+		for _, name := range implementations[def.Name] {
+			t.PossibleTypes = append(t.PossibleTypes, Type{Kind: "OBJECT", Name: name})
+		}
+		introspection.Schema.Types = append(introspection.Schema.Types, t)
+	}
+	return introspection
+}
+
+func schemaType(t *ast.Type) Type {
+	named := Type{Kind: "SCALAR", Name: t.NamedType}
+	if t.Elem != nil {
+		elem := schemaType(t.Elem)
+		named = Type{Kind: "LIST", OfType: &elem}
+	}
+	if t.NonNull {
+		return Type{Kind: "NON_NULL", OfType: &named}
+	}
+	return named
+}
+
+func TestRenderedTracingClientCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a generated client")
+	}
+
+	generated, err := os.ReadFile("../generated/generated.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buildTemplateData("generated", loadSchema(t, "../generated/schema.graphql"))
+	data.Tracing = true
+	if data.MoneyFields, err = findMoneyFields(generated); err != nil {
+		t.Fatalf("findMoneyFields() error = %v", err)
+	}
+	if data.TraitTypes, err = findTraitTypes(generated); err != nil {
+		t.Fatalf("findTraitTypes() error = %v", err)
+	}
+	files, err := renderTemplates(data)
+	if err != nil {
+		t.Fatalf("renderTemplates() error = %v", err)
+	}
+	files["generated.go"] = generated
+
+	// The directory is inside the module, so that the client builds against
+	// its packages, and starts with _ to stay out of ./... patterns.
+	dir, err := os.MkdirTemp(".", "_tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command("go", "vet", "./"+filepath.Base(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go vet of the client generated with tracing failed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build go1.23
+
+package generated
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestActivitiesOrder(t *testing.T) {
+	eye := newActivityClient(t, 12, 5*time.Millisecond, &pageTransport{})
+
+	i := 0
+	for activity, err := range eye.Activities(context.Background(), testDataKey, SourceNetflix) {
+		if err != nil {
+			t.Fatalf("Activities() error = %v", err)
+		}
+		i++
+		if title, want := activity.ToActivity().Title, fmt.Sprintf("Episode %d", i); title != want {
+			t.Errorf("activity %d = %s, want %s", i-1, title, want)
+		}
+	}
+	if i != 12 {
+		t.Errorf("Activities() yielded %d activities, want 12", i)
+	}
+}
+
+func TestActivitiesBreak(t *testing.T) {
+	transport := &pageTransport{}
+	eye := newActivityClient(t, 50, 50*time.Millisecond, transport, WithPrefetchWorkers(3))
+	goroutines := runtime.NumGoroutine()
+
+	seen := 0
+	for _, err := range eye.Activities(context.Background(), testDataKey, SourceNetflix) {
+		if err != nil {
+			t.Fatalf("Activities() error = %v", err)
+		}
+		seen++
+		if seen == 7 {
+			break
+		}
+	}
+	waitForGoroutines(t, goroutines)
+	if _, requests := transport.stats(); requests >= 10 {
+		t.Errorf("Activities() sent %d requests after the loop stopped, want fewer than the 10 pages", requests)
+	}
+}
+
+func TestActivitiesPageError(t *testing.T) {
+	eye := newActivityClient(t, 23, 5*time.Millisecond, &pageTransport{failPage: 3}, WithPrefetchWorkers(3))
+
+	seen := 0
+	var errs []error
+	for activity, err := range eye.Activities(context.Background(), testDataKey, SourceNetflix) {
+		if err != nil {
+			errs = append(errs, err)
+			if activity.Id != "" {
+				t.Errorf("Activities() yielded %+v with the error, want a zero activity", activity)
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Fatal("Activities() yielded an activity after an error")
+		}
+		seen++
+	}
+	if seen != 10 || len(errs) != 1 {
+		t.Errorf("Activities() yielded %d activities and %d errors, want 10 and 1", seen, len(errs))
+	}
+}
@@ -0,0 +1,231 @@
+package generated
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+const testDataKey = "MY_DATA_KEY"
+
+// activityFixtures returns n Netflix activities titled Episode 1 to Episode n.
+func activityFixtures(n int) []saurontest.Activity {
+	activities := make([]saurontest.Activity, n)
+	for i := range activities {
+		activities[i] = saurontest.Activity{
+			ID:       fmt.Sprintf("6a1a7a3e-8f4f-4c55-9d4b-%012d", i+1),
+			Type:     "WATCH",
+			Metadata: json.RawMessage(fmt.Sprintf(`{"__typename":"NetflixActivityMetadata","title":"Episode %d","date":"01/02/2024","lastPlayedAt":"01/03/2024"}`, i+1)),
+		}
+	}
+	return activities
+}
+
+// pageTransport counts the getActivity requests it sends and answers those
+// for failPage with a 500 instead of sending them. Connections are not kept
+// alive, so that no goroutine outlives a request.
+type pageTransport struct {
+	failPage int64
+	base     *http.Transport
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	requests    int
+}
+
+func (t *pageTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var gqlReq struct {
+		Variables struct {
+			Page int64 `json:"page"`
+		} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &gqlReq); err != nil {
+		return nil, err
+	}
+	if gqlReq.Variables.Page == t.failPage {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte("Internal Server Error"))),
+			Request:    r,
+		}, nil
+	}
+
+	t.mu.Lock()
+	t.inFlight++
+	t.requests++
+	if t.inFlight > t.maxInFlight {
+		t.maxInFlight = t.inFlight
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+
+	return t.base.RoundTrip(r)
+}
+
+func (t *pageTransport) stats() (maxInFlight, requests int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.maxInFlight, t.requests
+}
+
+// newActivityClient serves n activities from a saurontest server answering
+// after latency, and returns a client fetching pages of 5 activities
+// with transport.
+func newActivityClient(t *testing.T, n int, latency time.Duration, transport *pageTransport, opts ...Option) *EyeOfSauron {
+	t.Helper()
+
+	transport.base = &http.Transport{DisableKeepAlives: true}
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps:       []saurontest.App{saurontest.TestApp},
+		Activities: map[string][]saurontest.Activity{testDataKey: activityFixtures(n)},
+	}, saurontest.WithLatency(latency))
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{
+		WithEndpoint(server.URL),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithPageSize(5),
+	}, opts...)
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey, opts...)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	return eye
+}
+
+// waitForGoroutines fails the test unless the number of goroutines drops to
+// at most want, which leaves time for the canceled workers to return.
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := runtime.NumGoroutine()
+		if got <= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines are running, want at most %d:\n%s", got, want, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEachActivityOrder(t *testing.T) {
+	for _, workers := range []int{1, 3, 10} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			transport := &pageTransport{}
+			eye := newActivityClient(t, 23, 5*time.Millisecond, transport, WithPrefetchWorkers(workers))
+
+			var titles []string
+			err := eye.EachActivity(context.Background(), testDataKey, SourceNetflix, func(activity Activity) error {
+				titles = append(titles, activity.ToActivity().Title)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("EachActivity() error = %v", err)
+			}
+			if len(titles) != 23 {
+				t.Fatalf("EachActivity() returned %d activities, want 23", len(titles))
+			}
+			for i, title := range titles {
+				if want := fmt.Sprintf("Episode %d", i+1); title != want {
+					t.Errorf("activity %d = %s, want %s", i, title, want)
+				}
+			}
+			if _, requests := transport.stats(); requests != 5 {
+				t.Errorf("EachActivity() sent %d requests, want 5 pages", requests)
+			}
+		})
+	}
+}
+
+func TestEachActivityPrefetchWorkers(t *testing.T) {
+	for _, workers := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			transport := &pageTransport{}
+			eye := newActivityClient(t, 50, 20*time.Millisecond, transport, WithPrefetchWorkers(workers))
+
+			// A slow reader lets the workers run ahead as far as they may.
+			err := eye.EachActivity(context.Background(), testDataKey, SourceNetflix, func(Activity) error {
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("EachActivity() error = %v", err)
+			}
+			if maxInFlight, _ := transport.stats(); maxInFlight != workers {
+				t.Errorf("EachActivity() sent %d requests at a time, want %d", maxInFlight, workers)
+			}
+		})
+	}
+}
+
+func TestEachActivityCancel(t *testing.T) {
+	transport := &pageTransport{}
+	eye := newActivityClient(t, 50, 50*time.Millisecond, transport, WithPrefetchWorkers(3))
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seen := 0
+	err := eye.EachActivity(ctx, testDataKey, SourceNetflix, func(Activity) error {
+		seen++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("EachActivity() error = %v, want %v", err, context.Canceled)
+	}
+	if seen >= 50 {
+		t.Errorf("EachActivity() returned %d activities after the context was canceled", seen)
+	}
+	waitForGoroutines(t, goroutines)
+}
+
+func TestEachActivityPageError(t *testing.T) {
+	for _, failPage := range []int64{1, 2, 4} {
+		t.Run(fmt.Sprintf("page %d", failPage), func(t *testing.T) {
+			transport := &pageTransport{failPage: failPage}
+			eye := newActivityClient(t, 23, 5*time.Millisecond, transport, WithPrefetchWorkers(3))
+
+			var titles []string
+			err := eye.EachActivity(context.Background(), testDataKey, SourceNetflix, func(activity Activity) error {
+				titles = append(titles, activity.ToActivity().Title)
+				return nil
+			})
+			var statusErr *graphql.StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+				t.Errorf("EachActivity() error = %v, want a 500", err)
+			}
+			if want := int(failPage-1) * 5; len(titles) != want {
+				t.Errorf("EachActivity() returned %d activities, want the %d of the pages before %d", len(titles), want, failPage)
+			}
+		})
+	}
+}
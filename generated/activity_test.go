@@ -0,0 +1,160 @@
+package generated
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToActivity(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		metadata string
+		want     NormalizedActivity
+		wantType ActivityMetadata
+	}{
+		{
+			name:     "amazon",
+			metadata: `{"__typename":"AmazonActivityMetadata","productName":"Kindle","date":"03/04/2024","quantityPurchased":1,"totalCost":"99.99","subject":[{"value":"B09SWRYPB2","identifierType":"ASIN"}]}`,
+			want: NormalizedActivity{
+				Source:      SourceAmazon,
+				Kind:        ActivityTypeShop,
+				Title:       "Kindle",
+				OccurredAt:  date(time.March, 4),
+				Amount:      "99.99",
+				Identifiers: []ActivityIdentifier{{Value: "B09SWRYPB2", IdentifierType: IdentifierTypeAsin}},
+			},
+			wantType: &AmazonActivityMetadata{},
+		},
+		{
+			name:     "booking",
+			metadata: `{"__typename":"BookingActivityMetadata","bookingID":"B-1","price":"EUR 120.50","bookings":[]}`,
+			want: NormalizedActivity{
+				Source: SourceBooking,
+				Amount: "EUR 120.50",
+			},
+			wantType: &BookingActivityMetadata{},
+		},
+		{
+			name:     "instacart",
+			metadata: `{"__typename":"InstacartActivityMetadata","retailer":"Costco","totalOrderAmountSpent":"45.10","dateOrdered":"05/06/2024","dateDelivered":"05/07/2024","statusString":"complete"}`,
+			want: NormalizedActivity{
+				Source:     SourceInstacart,
+				Kind:       ActivityTypeShop,
+				Title:      "Costco",
+				OccurredAt: date(time.May, 6),
+				Amount:     "45.10",
+			},
+			wantType: &InstacartActivityMetadata{},
+		},
+		{
+			name:     "netflix",
+			metadata: `{"__typename":"NetflixActivityMetadata","title":"Dark","date":"01/02/2024","lastPlayedAt":"01/03/2024","subject":[{"value":"tt5753856","identifierType":"IMDB"}]}`,
+			want: NormalizedActivity{
+				Source:      SourceNetflix,
+				Kind:        ActivityTypeWatch,
+				Title:       "Dark",
+				OccurredAt:  date(time.January, 2),
+				Identifiers: []ActivityIdentifier{{Value: "tt5753856", IdentifierType: IdentifierTypeImdb}},
+			},
+			wantType: &NetflixActivityMetadata{},
+		},
+		{
+			name:     "playstation",
+			metadata: `{"__typename":"PlaystationActivityMetadata","title":"Astro Bot","lastPlayedAt":"09/10/2024"}`,
+			want: NormalizedActivity{
+				Source:     SourcePlaystation,
+				Kind:       ActivityTypePlay,
+				Title:      "Astro Bot",
+				OccurredAt: date(time.September, 10),
+			},
+			wantType: &PlaystationActivityMetadata{},
+		},
+		{
+			name:     "uber",
+			metadata: `{"__typename":"UberActivityMetadata","beginTripTime":"2024-07-08T09:10:11Z","dropoffTime":"2024-07-08T09:40:00Z","cost":"USD 23.40","city":"Austin","distance":"8.1","UberActivityMetadataStatus":"COMPLETED"}`,
+			want: NormalizedActivity{
+				Source:     SourceUber,
+				Kind:       ActivityTypeTrip,
+				OccurredAt: time.Date(2024, time.July, 8, 9, 10, 11, 0, time.UTC),
+				Amount:     "USD 23.40",
+			},
+			wantType: &UberActivityMetadata{},
+		},
+		{
+			name:     "ubereats",
+			metadata: `{"__typename":"UberEatsActivityMetadata","date":"11/12/2024","restaurant":"Tacodeli","currency":"usd","totalPrice":18.5,"status":"SUCCESS"}`,
+			want: NormalizedActivity{
+				Source:     SourceUbereats,
+				Kind:       ActivityTypeShop,
+				Title:      "Tacodeli",
+				OccurredAt: date(time.November, 12),
+				Amount:     "18.5",
+				Currency:   "usd",
+			},
+			wantType: &UberEatsActivityMetadata{},
+		},
+		{
+			name:     "youtube",
+			metadata: `{"__typename":"YoutubeActivityMetadata","title":"Go 1.22 release party","date":"02/13/2024","percentageWatched":80,"contentType":"VIDEO"}`,
+			want: NormalizedActivity{
+				Source:     SourceYoutube,
+				Kind:       ActivityTypeWatch,
+				Title:      "Go 1.22 release party",
+				OccurredAt: date(time.February, 13),
+			},
+			wantType: &YoutubeActivityMetadata{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const id = "6a1a7a3e-8f4f-4c55-9d4b-000000000001"
+			body := `{"lookupActivity":{"id":"` + id + `","metadata":` + tt.metadata + `}}`
+			var resp lookupActivityResponse
+			if err := json.Unmarshal([]byte(body), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			got := resp.LookupActivity.ToActivity()
+			if reflect.TypeOf(got.Metadata) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("Metadata is %T, want %T", got.Metadata, tt.wantType)
+			}
+			got.Metadata = nil
+			tt.want.ID = id
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToActivity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToActivityWithoutMetadata(t *testing.T) {
+	var resp lookupActivityResponse
+	if err := json.Unmarshal([]byte(`{"lookupActivity":{"id":"a1","metadata":null}}`), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got, want := resp.LookupActivity.ToActivity(), (NormalizedActivity{ID: "a1"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToActivity() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNormalizedActivityMoney(t *testing.T) {
+	activity := (&UberEatsActivityMetadata{Currency: "usd", TotalPrice: 18.5}).ToActivity("a1")
+	money, err := activity.Money()
+	if err != nil {
+		t.Fatalf("Money: %v", err)
+	}
+	if got := money.String(); got != "18.5 USD" {
+		t.Errorf("Money() = %s, want 18.5 USD", got)
+	}
+
+	money, err = (&NetflixActivityMetadata{}).ToActivity("a2").Money()
+	if err != nil || !money.IsZero() || money.Currency != "" {
+		t.Errorf("Money() without amount = %s, %v, want zero", money, err)
+	}
+}
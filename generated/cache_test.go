@@ -0,0 +1,253 @@
+package generated
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+	"github.com/google/uuid"
+)
+
+var testTraitID = uuid.MustParse("6a1a7a3e-8f4f-4c55-9d4b-0b9e1f1f6a03")
+
+func traitKey(dataKey, id string) CacheKey {
+	return CacheKey{Operation: "lookupTrait", DataKey: dataKey, ID: id}
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(3, 0)
+	for _, id := range []string{"1", "2", "3"} {
+		cache.Set(ctx, traitKey(testDataKey, id), []byte(id))
+	}
+
+	// Reading 1 makes 2 the least recently used response, then 3.
+	if _, ok := cache.Get(ctx, traitKey(testDataKey, "1")); !ok {
+		t.Fatal("Get(1) missed before any eviction")
+	}
+	cache.Set(ctx, traitKey(testDataKey, "4"), []byte("4"))
+	// Updating 3 makes it recently used too, leaving 1 to go next.
+	cache.Set(ctx, traitKey(testDataKey, "3"), []byte("3'"))
+	cache.Set(ctx, traitKey(testDataKey, "5"), []byte("5"))
+
+	want := map[string]string{"3": "3'", "4": "4", "5": "5"}
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		value, ok := cache.Get(ctx, traitKey(testDataKey, id))
+		if wantValue, cached := want[id]; ok != cached || string(value) != wantValue {
+			t.Errorf("Get(%s) = %q, %v, want %q, %v", id, value, ok, wantValue, cached)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", cache.Len())
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(10, 100*time.Millisecond)
+	cache.Set(ctx, traitKey(testDataKey, "1"), []byte("1"))
+	time.Sleep(60 * time.Millisecond)
+	cache.Set(ctx, traitKey(testDataKey, "2"), []byte("2"))
+
+	if _, ok := cache.Get(ctx, traitKey(testDataKey, "1")); !ok {
+		t.Error("Get(1) missed before its ttl")
+	}
+	time.Sleep(60 * time.Millisecond)
+	// Reading a response does not extend its ttl.
+	if _, ok := cache.Get(ctx, traitKey(testDataKey, "1")); ok {
+		t.Error("Get(1) hit after its ttl")
+	}
+	if _, ok := cache.Get(ctx, traitKey(testDataKey, "2")); !ok {
+		t.Error("Get(2) missed before its ttl")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want the expired response evicted", cache.Len())
+	}
+
+	forever := NewMemoryCache(10, 0)
+	forever.Set(ctx, traitKey(testDataKey, "1"), []byte("1"))
+	time.Sleep(120 * time.Millisecond)
+	if _, ok := forever.Get(ctx, traitKey(testDataKey, "1")); !ok {
+		t.Error("Get(1) missed with no ttl")
+	}
+}
+
+func TestMemoryCacheInvalidateDataKey(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(10, 0)
+	keys := []CacheKey{
+		traitKey("DATA_KEY_A", "1"),
+		traitKey("DATA_KEY_A", "2"),
+		{Operation: "lookupActivity", DataKey: "DATA_KEY_A", ID: "1"},
+		traitKey("DATA_KEY_B", "1"),
+		{Operation: "lookupActivity", DataKey: "DATA_KEY_B", ID: "1"},
+	}
+	for _, key := range keys {
+		cache.Set(ctx, key, []byte(key.ID))
+	}
+
+	if err := cache.InvalidateDataKey(ctx, "DATA_KEY_A"); err != nil {
+		t.Fatalf("InvalidateDataKey() error = %v", err)
+	}
+	for _, key := range keys {
+		if _, ok := cache.Get(ctx, key); ok != (key.DataKey == "DATA_KEY_B") {
+			t.Errorf("Get(%+v) hit = %v after invalidating DATA_KEY_A", key, ok)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want the 2 responses of DATA_KEY_B", cache.Len())
+	}
+}
+
+// newCachedTraitClient returns a client caching in cache the traits served by
+// a saurontest server.
+func newCachedTraitClient(t *testing.T, cache Cache) (*saurontest.Server, *EyeOfSauron) {
+	t.Helper()
+
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps: []saurontest.App{saurontest.TestApp},
+		Traits: map[string][]saurontest.Trait{
+			testDataKey: {{ID: testTraitID.String(), Source: "NETFLIX", Label: "PLAN", Value: "premium", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		},
+	})
+	t.Cleanup(server.Close)
+
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey,
+		WithEndpoint(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithCache(cache),
+	)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	return server, eye
+}
+
+func TestInvalidateCache(t *testing.T) {
+	cache := NewMemoryCache(10, 0)
+	server, eye := newCachedTraitClient(t, cache)
+	ctx := context.Background()
+	cache.Set(ctx, traitKey("OTHER_DATA_KEY", testTraitID.String()), []byte(`{}`))
+
+	for i := 0; i < 2; i++ {
+		resp, err := eye.LookupTrait(ctx, testDataKey, testTraitID)
+		if err != nil {
+			t.Fatalf("LookupTrait() error = %v", err)
+		}
+		if resp.LookupTrait.Value != "premium" {
+			t.Errorf("LookupTrait() = %+v, want the premium plan", resp.LookupTrait)
+		}
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("server received %d requests, want 1 with the second lookup cached", got)
+	}
+
+	if err := eye.InvalidateCache(ctx, testDataKey); err != nil {
+		t.Fatalf("InvalidateCache() error = %v", err)
+	}
+	if _, err := eye.LookupTrait(ctx, testDataKey, testTraitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("server received %d requests, want 2 once the cache is invalidated", got)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want the lookup and the response of OTHER_DATA_KEY", cache.Len())
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	cache := NewMemoryCache(10, 0)
+	server, eye := newCachedTraitClient(t, cache)
+	ctx := context.Background()
+
+	server.Inject("lookupTrait", saurontest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if _, err := eye.LookupTrait(ctx, testDataKey, testTraitID); err == nil {
+		t.Fatal("LookupTrait() expected the injected error")
+	}
+	server.Inject("lookupTrait", saurontest.Fault{Errors: []saurontest.Error{{Message: "trait not found", Code: "NOT_FOUND"}}, Times: 1})
+	if _, err := eye.LookupTrait(ctx, testDataKey, testTraitID); err == nil {
+		t.Fatal("LookupTrait() expected the injected GraphQL error")
+	}
+	if _, err := eye.LookupTrait(ctx, testDataKey, uuid.New()); err == nil {
+		t.Fatal("LookupTrait() of an unknown trait expected an error")
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("Len() = %d after failed lookups, want 0", cache.Len())
+	}
+
+	if _, err := eye.LookupTrait(ctx, testDataKey, testTraitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d after a successful lookup, want 1", cache.Len())
+	}
+}
+
+func TestCacheSkipsPartialData(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"lookupTrait":{"id":"` + testTraitID.String() + `","source":"NETFLIX","label":"PLAN","value":"premium","timestamp":"2024-01-02T00:00:00Z"}},"errors":[{"message":"rating unavailable","path":["lookupTrait","rating"]}]}`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache(10, 0)
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey,
+		WithEndpoint(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithCache(cache),
+		WithPartialData(),
+	)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID)
+		if err == nil || resp == nil || resp.LookupTrait.Value != "premium" {
+			t.Fatalf("LookupTrait() = %+v, %v, want the partial data and an error", resp, err)
+		}
+	}
+	if cache.Len() != 0 || requests.Load() != 2 {
+		t.Errorf("cached %d responses and sent %d requests, want partial data never cached", cache.Len(), requests.Load())
+	}
+}
+
+func TestCacheSkipsMutations(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"lookupTrait":{"value":"premium"}}}`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache(10, 0)
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey, WithEndpoint(server.URL), WithCache(cache))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+
+	// The mutation has the name and variables of a cached lookup.
+	req := graphql.NewRequest(`mutation lookupTrait ($dataKey: String!, $traitId: UUID!) {
+	lookupTrait(dataKey: $dataKey, traitId: $traitId) { value }
+}`)
+	req.Var("dataKey", testDataKey)
+	req.Var("traitId", testTraitID)
+	for i := 0; i < 2; i++ {
+		var resp map[string]interface{}
+		if err := eye.run(context.Background(), req, &resp); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+	}
+	if cache.Len() != 0 || requests.Load() != 2 {
+		t.Errorf("cached %d responses and sent %d requests, want mutations never cached", cache.Len(), requests.Load())
+	}
+}
@@ -3,71 +3,17 @@
 package generated
 
 import (
-	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec/v2"
 	graphql2 "github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
 	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphqlTypes"
 	"github.com/gandalf-network/genqlient/graphql"
 	"github.com/google/uuid"
 )
 
-type EyeOfSauron struct {
-	client     *graphql2.Client
-	privateKey *ecdsa.PrivateKey
-}
-
-func NewEyeOfSauron(privateKey string) (*EyeOfSauron, error) {
-	client := graphql2.NewClient("https://sauron.gandalf.network/public/gql")
-	privKey, err := HexToECDSAPrivateKey(privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %v", err)
-	}
-	return &EyeOfSauron{
-		privateKey: privKey,
-		client:     client,
-	}, nil
-}
-
-// HexToECDSAPrivateKey converts a hexadecimal string representing a private key
-// into an *ecdsa.PrivateKey for the secp256k1 curve.
-func HexToECDSAPrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
-	trimmedHexKey := strings.TrimPrefix(hexKey, "0x")
-
-	privKeyBytes, err := hex.DecodeString(trimmedHexKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode hex string: %v", err)
-	}
-
-	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
-
-	return privKey.ToECDSA(), nil
-}
-
-// SignMessage signs a message using the given ECDSA private key.
-func SignMessageAsBase64(privKey *ecdsa.PrivateKey, message []byte) (string, error) {
-	hash := sha256.Sum256(message)
-
-	signature, err := ecdsa.SignASN1(rand.Reader, privKey, hash[:])
-	if err != nil {
-		return "", fmt.Errorf("failed to sign message: %v", err)
-	}
-
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
-
-	return signatureB64, nil
-}
-
 type ActivityType string
 
 const (
@@ -3072,35 +3018,14 @@ func (eye EyeOfSauron) GetActivity(
 	req.Var("source", source)
 	req.Var("limit", limit)
 	req.Var("page", page)
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.Query(),
-		Variables: req.Vars(),
-	}
-
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return nil, fmt.Errorf("unable to encode body %s", err)
-	}
-
-	var err_ error
-	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
-	if err_ != nil {
-		return nil, fmt.Errorf("unable to generate signature: %v", err_)
-	}
-
-	req.Header.Set("X-Gandalf-Signature", signatureB64)
-
 	var resp_ getActivityResponse
 
-	if err_ := eye.client.Run(
+	if err_ := eye.run(
 		ctx_,
 		req,
 		&resp_,
 	); err_ != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err_)
+		return partialResponse(eye, &resp_, err_)
 	}
 
 	return &resp_, nil
@@ -3128,35 +3053,14 @@ func (eye EyeOfSauron) GetAppByPublicKey(
 	req := graphql2.NewRequest(getAppByPublicKey_Operation)
 
 	req.Var("publicKey", publicKey)
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.Query(),
-		Variables: req.Vars(),
-	}
-
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return nil, fmt.Errorf("unable to encode body %s", err)
-	}
-
-	var err_ error
-	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
-	if err_ != nil {
-		return nil, fmt.Errorf("unable to generate signature: %v", err_)
-	}
-
-	req.Header.Set("X-Gandalf-Signature", signatureB64)
-
 	var resp_ getAppByPublicKeyResponse
 
-	if err_ := eye.client.Run(
+	if err_ := eye.run(
 		ctx_,
 		req,
 		&resp_,
 	); err_ != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err_)
+		return partialResponse(eye, &resp_, err_)
 	}
 
 	return &resp_, nil
@@ -3188,35 +3092,14 @@ func (eye EyeOfSauron) GetTraits(
 	req.Var("dataKey", dataKey)
 	req.Var("source", source)
 	req.Var("labels", labels)
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.Query(),
-		Variables: req.Vars(),
-	}
-
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return nil, fmt.Errorf("unable to encode body %s", err)
-	}
-
-	var err_ error
-	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
-	if err_ != nil {
-		return nil, fmt.Errorf("unable to generate signature: %v", err_)
-	}
-
-	req.Header.Set("X-Gandalf-Signature", signatureB64)
-
 	var resp_ getTraitsResponse
 
-	if err_ := eye.client.Run(
+	if err_ := eye.run(
 		ctx_,
 		req,
 		&resp_,
 	); err_ != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err_)
+		return partialResponse(eye, &resp_, err_)
 	}
 
 	return &resp_, nil
@@ -3382,35 +3265,14 @@ func (eye EyeOfSauron) LookupActivity(
 
 	req.Var("dataKey", dataKey)
 	req.Var("activityId", activityId)
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.Query(),
-		Variables: req.Vars(),
-	}
-
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return nil, fmt.Errorf("unable to encode body %s", err)
-	}
-
-	var err_ error
-	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
-	if err_ != nil {
-		return nil, fmt.Errorf("unable to generate signature: %v", err_)
-	}
-
-	req.Header.Set("X-Gandalf-Signature", signatureB64)
-
 	var resp_ lookupActivityResponse
 
-	if err_ := eye.client.Run(
+	if err_ := eye.run(
 		ctx_,
 		req,
 		&resp_,
 	); err_ != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err_)
+		return partialResponse(eye, &resp_, err_)
 	}
 
 	return &resp_, nil
@@ -3440,35 +3302,14 @@ func (eye EyeOfSauron) LookupTrait(
 
 	req.Var("dataKey", dataKey)
 	req.Var("traitId", traitId)
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.Query(),
-		Variables: req.Vars(),
-	}
-
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return nil, fmt.Errorf("unable to encode body %s", err)
-	}
-
-	var err_ error
-	signatureB64, err_ := SignMessageAsBase64(eye.privateKey, requestBody.Bytes())
-	if err_ != nil {
-		return nil, fmt.Errorf("unable to generate signature: %v", err_)
-	}
-
-	req.Header.Set("X-Gandalf-Signature", signatureB64)
-
 	var resp_ lookupTraitResponse
 
-	if err_ := eye.client.Run(
+	if err_ := eye.run(
 		ctx_,
 		req,
 		&resp_,
 	); err_ != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err_)
+		return partialResponse(eye, &resp_, err_)
 	}
 
 	return &resp_, nil
@@ -0,0 +1,89 @@
+package generated
+
+import (
+	"testing"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphqlTypes"
+)
+
+func TestMoneyAccessors(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    func() (graphqlTypes.Money, error)
+		currency string
+		units    int64
+		scale    int
+	}{
+		{
+			name: "amazon total cost",
+			money: func() (graphqlTypes.Money, error) {
+				return (&AmazonActivityMetadata{TotalCost: "$1,234.50"}).TotalCostMoney()
+			},
+			currency: "USD", units: 123450, scale: 2,
+		},
+		{
+			name: "booking price",
+			money: func() (graphqlTypes.Money, error) {
+				return (&BookingActivityMetadata{Price: "EUR 120.5"}).PriceMoney()
+			},
+			currency: "EUR", units: 1205, scale: 1,
+		},
+		{
+			name: "instacart total",
+			money: func() (graphqlTypes.Money, error) {
+				return (&InstacartActivityMetadata{TotalOrderAmountSpent: "45.10"}).TotalOrderAmountSpentMoney()
+			},
+			units: 4510, scale: 2,
+		},
+		{
+			name: "instacart unit price",
+			money: func() (graphqlTypes.Money, error) {
+				return (&InstacartActivityMetadataInstacartActivityMetadataItemsInstacartOrderItem{UnitPrice: "£0.99"}).UnitPriceMoney()
+			},
+			currency: "GBP", units: 99, scale: 2,
+		},
+		{
+			name: "uber cost",
+			money: func() (graphqlTypes.Money, error) {
+				return (&UberActivityMetadata{Cost: "¥1500"}).CostMoney()
+			},
+			currency: "JPY", units: 1500, scale: 0,
+		},
+		{
+			name: "ubereats total price",
+			money: func() (graphqlTypes.Money, error) {
+				return (&UberEatsActivityMetadata{TotalPrice: 18.25, Currency: "usd"}).TotalPriceMoney()
+			},
+			currency: "USD", units: 1825, scale: 2,
+		},
+		{
+			name: "ubereats item price",
+			money: func() (graphqlTypes.Money, error) {
+				return (&UberEatsActivityMetadataItemsUberEatsOrderItem{Price: "4,50 €"}).PriceMoney()
+			},
+			currency: "EUR", units: 450, scale: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := tt.money()
+			if err != nil {
+				t.Fatalf("money error = %v", err)
+			}
+			units, scale := money.Units()
+			if money.Currency != tt.currency || units != tt.units || scale != tt.scale {
+				t.Errorf("money = %d×10^-%d %q, want %d×10^-%d %q", units, scale, money.Currency, tt.units, tt.scale, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyAccessorsInvalid(t *testing.T) {
+	if _, err := (&AmazonActivityMetadata{TotalCost: ""}).TotalCostMoney(); err == nil {
+		t.Error("TotalCostMoney() of an empty amount succeeded")
+	}
+	if _, err := (&UberActivityMetadata{Cost: "free"}).CostMoney(); err == nil {
+		t.Error("CostMoney() of free succeeded")
+	}
+}
@@ -0,0 +1,199 @@
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+func dataKeyRequest(dataKey string) *graphql.Request {
+	req := graphql.NewRequest(getTraits_Operation)
+	if dataKey != "" {
+		req.Var("dataKey", dataKey)
+	}
+	return req
+}
+
+// timedWait returns how long the limiter made a request for dataKey wait.
+func timedWait(t *testing.T, ctx context.Context, l *rateLimiter, dataKey string) (time.Duration, error) {
+	t.Helper()
+
+	start := time.Now()
+	err := l.wait(ctx, dataKeyRequest(dataKey))
+	return time.Since(start), err
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	l := newRateLimiter(&RateLimit{Rate: 20, Burst: 3}, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if waited, err := timedWait(t, ctx, l, testDataKey); err != nil || waited > 10*time.Millisecond {
+			t.Errorf("request %d of the burst waited %v, %v, want no wait", i+1, waited, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		// Tokens come back every 50ms once the burst is spent.
+		if waited, err := timedWait(t, ctx, l, testDataKey); err != nil || waited < 40*time.Millisecond || waited > 150*time.Millisecond {
+			t.Errorf("request %d after the burst waited %v, %v, want about 50ms", i+1, waited, err)
+		}
+	}
+}
+
+func TestDataKeyRateLimit(t *testing.T) {
+	l := newRateLimiter(nil, &RateLimit{Rate: 1, Burst: 1})
+	ctx := context.Background()
+
+	for _, dataKey := range []string{"DATA_KEY_A", "DATA_KEY_B", ""} {
+		if waited, err := timedWait(t, ctx, l, dataKey); err != nil || waited > 10*time.Millisecond {
+			t.Errorf("first request for %q waited %v, %v, want no wait", dataKey, waited, err)
+		}
+	}
+	// Requests without a data key are not limited.
+	if waited, err := timedWait(t, ctx, l, ""); err != nil || waited > 10*time.Millisecond {
+		t.Errorf("second request without a data key waited %v, %v, want no wait", waited, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := timedWait(t, ctx, l, "DATA_KEY_A"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second request for DATA_KEY_A error = %v, want a wait past the deadline", err)
+	}
+	if waited, err := timedWait(t, context.Background(), l, "DATA_KEY_C"); err != nil || waited > 10*time.Millisecond {
+		t.Errorf("first request for DATA_KEY_C waited %v, %v, want no wait", waited, err)
+	}
+}
+
+func TestRateLimitCancelRefundsTokens(t *testing.T) {
+	l := newRateLimiter(&RateLimit{Rate: 10, Burst: 1}, &RateLimit{Rate: 10, Burst: 1})
+	if _, err := timedWait(t, context.Background(), l, testDataKey); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := timedWait(t, ctx, l, testDataKey); !errors.Is(err, context.Canceled) {
+			t.Fatalf("wait() with a canceled context error = %v, want %v", err, context.Canceled)
+		}
+	}
+
+	// Had the canceled requests kept their tokens, this one would wait 400ms.
+	if waited, err := timedWait(t, context.Background(), l, testDataKey); err != nil || waited > 150*time.Millisecond {
+		t.Errorf("request after the canceled ones waited %v, %v, want at most 100ms", waited, err)
+	}
+	if stats := l.stats(); stats.Waited != 1 || stats.Waiting != 0 {
+		t.Errorf("stats() = %+v, want 1 wait and none canceled", stats)
+	}
+}
+
+func TestDataKeyBucketsEvicted(t *testing.T) {
+	l := newRateLimiter(nil, &RateLimit{Rate: 50, Burst: 1})
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		if _, err := timedWait(t, ctx, l, fmt.Sprintf("IDLE_%d", i)); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	// BUSY owes 10 tokens, which take 200ms to come back.
+	for i := 0; i < 11; i++ {
+		l.reserveDataKey("BUSY")
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// The idle buckets have refilled by now and are evicted once enough new
+	// data keys are seen.
+	for i := 0; len(l.dataKeyBuckets) > minDataKeyEviction; i++ {
+		if i == 1000 {
+			t.Fatalf("%d data key buckets after %d new data keys, want full buckets evicted", len(l.dataKeyBuckets), i)
+		}
+		if _, err := timedWait(t, ctx, l, fmt.Sprintf("NEW_%d", i)); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	for dataKey := range l.dataKeyBuckets {
+		if strings.HasPrefix(dataKey, "IDLE_") {
+			t.Errorf("bucket of %s was not evicted", dataKey)
+		}
+	}
+	if bucket, ok := l.dataKeyBuckets["BUSY"]; !ok || bucket.full(time.Now()) {
+		t.Error("bucket of BUSY was evicted before it refilled")
+	}
+}
+
+func TestRateLimitStats(t *testing.T) {
+	l := newRateLimiter(&RateLimit{Rate: 10, Burst: 1}, nil)
+	if _, err := timedWait(t, context.Background(), l, ""); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if stats := l.stats(); stats != (RateLimitStats{}) {
+		t.Errorf("stats() after a request within the burst = %+v, want zero", stats)
+	}
+
+	done := make(chan time.Duration)
+	go func() {
+		waited, _ := timedWait(t, context.Background(), l, "")
+		done <- waited
+	}()
+	for deadline := time.Now().Add(50 * time.Millisecond); l.stats().Waiting != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats() = %+v, want 1 waiting", l.stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waited := <-done
+
+	stats := l.stats()
+	if stats.Waiting != 0 || stats.Waited != 1 {
+		t.Errorf("stats() = %+v, want 1 waited and none waiting", stats)
+	}
+	if stats.MaxWait != stats.TotalWait || stats.MaxWait < 90*time.Millisecond || stats.MaxWait > waited {
+		t.Errorf("stats() = %+v, want a wait of about 100ms", stats)
+	}
+
+	// A canceled wait leaves the stats unchanged.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := timedWait(t, ctx, l, ""); err == nil {
+		t.Fatal("wait() expected the context error")
+	}
+	if got := l.stats(); got != stats {
+		t.Errorf("stats() after a canceled wait = %+v, want %+v", got, stats)
+	}
+}
+
+func TestRateLimitStatsClient(t *testing.T) {
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps:   []saurontest.App{saurontest.TestApp},
+		Traits: map[string][]saurontest.Trait{testDataKey: {}},
+	})
+	defer server.Close()
+
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey, WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	if stats := eye.RateLimitStats(); stats != (RateLimitStats{}) {
+		t.Errorf("RateLimitStats() without a limit = %+v, want zero", stats)
+	}
+
+	eye, err = NewEyeOfSauron(saurontest.PrivateKey, WithEndpoint(server.URL), WithRateLimit(RateLimit{Rate: 5, Burst: 2}))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := eye.GetTraits(context.Background(), testDataKey, SourceNetflix, []TraitLabel{TraitLabelPlan}); err != nil {
+			t.Fatalf("GetTraits() error = %v", err)
+		}
+	}
+	if stats := eye.RateLimitStats(); stats.Waited != 2 || stats.Waiting != 0 || stats.TotalWait < stats.MaxWait || stats.MaxWait <= 0 {
+		t.Errorf("RateLimitStats() after 4 requests with a burst of 2 = %+v, want 2 waits", stats)
+	}
+}
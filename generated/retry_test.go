@@ -0,0 +1,327 @@
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+// fastRetries retries quickly so that the tests do not wait on backoff.
+var fastRetries = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+	Multiplier:     2,
+}
+
+// countingSigner counts the requests it signs.
+type countingSigner struct {
+	Signer
+	signed atomic.Int32
+}
+
+func (s *countingSigner) Sign(ctx context.Context, body []byte) (string, error) {
+	s.signed.Add(1)
+	return s.Signer.Sign(ctx, body)
+}
+
+// newRetryClient returns a client retrying with policy against a saurontest
+// server holding one trait, and the signer of its requests.
+func newRetryClient(t *testing.T, policy RetryPolicy, opts ...Option) (*saurontest.Server, *EyeOfSauron, *countingSigner) {
+	t.Helper()
+
+	server := saurontest.NewServer(&saurontest.Fixtures{
+		Apps: []saurontest.App{saurontest.TestApp},
+		Traits: map[string][]saurontest.Trait{
+			testDataKey: {{ID: testTraitID.String(), Source: "NETFLIX", Label: "PLAN", Value: "premium", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		},
+	})
+	t.Cleanup(server.Close)
+
+	privateKey, err := HexToECDSAPrivateKey(saurontest.PrivateKey)
+	if err != nil {
+		t.Fatalf("HexToECDSAPrivateKey() error = %v", err)
+	}
+	signer := &countingSigner{Signer: NewPrivateKeySigner(privateKey)}
+	opts = append([]Option{
+		WithEndpoint(server.URL),
+		WithRetryPolicy(policy),
+		WithSigner(signer),
+	}, opts...)
+	eye, err := NewEyeOfSauron("", opts...)
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	return server, eye, signer
+}
+
+func TestRetryStatus(t *testing.T) {
+	tests := []struct {
+		status       int
+		wantRequests int
+		wantErr      bool
+	}{
+		{status: http.StatusTooManyRequests, wantRequests: 3},
+		{status: http.StatusInternalServerError, wantRequests: 3},
+		{status: http.StatusBadGateway, wantRequests: 3},
+		{status: http.StatusServiceUnavailable, wantRequests: 3},
+		{status: http.StatusBadRequest, wantRequests: 1, wantErr: true},
+		{status: http.StatusUnauthorized, wantRequests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server, eye, signer := newRetryClient(t, fastRetries)
+			server.Inject("lookupTrait", saurontest.Fault{StatusCode: tt.status, Times: 2})
+
+			_, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("LookupTrait() error = %v, want error %v", err, tt.wantErr)
+			}
+			requests := server.Requests()
+			if len(requests) != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", len(requests), tt.wantRequests)
+			}
+			// Every attempt is signed again and verified by the server.
+			if got := int(signer.signed.Load()); got != len(requests) {
+				t.Errorf("signed %d requests, want one signature for each of the %d attempts", got, len(requests))
+			}
+			for i, req := range requests {
+				if req.PublicKey != saurontest.TestApp.PublicKey {
+					t.Errorf("attempt %d was not signed by the test app", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, eye, _ := newRetryClient(t, fastRetries)
+	server.Inject("lookupTrait", saurontest.Fault{StatusCode: http.StatusServiceUnavailable})
+
+	_, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID)
+	var statusErr *graphql.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("LookupTrait() error = %v, want the last 503", err)
+	}
+	if got := len(server.Requests()); got != fastRetries.MaxAttempts {
+		t.Errorf("server received %d requests, want %d", got, fastRetries.MaxAttempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	policy := fastRetries
+	policy.MaxBackoff = 5 * time.Second
+	server, eye, _ := newRetryClient(t, policy)
+	server.Inject("lookupTrait", saurontest.Fault{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"1"}},
+		Times:      1,
+	})
+
+	start := time.Now()
+	if _, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 2*time.Second {
+		t.Errorf("LookupTrait() took %v, want the 1s asked by Retry-After", elapsed)
+	}
+
+	// MaxBackoff caps the wait asked for by the server.
+	policy.MaxBackoff = 20 * time.Millisecond
+	server, eye, _ = newRetryClient(t, policy)
+	server.Inject("lookupTrait", saurontest.Fault{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": {"60"}},
+		Times:      1,
+	})
+	start = time.Now()
+	if _, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("LookupTrait() took %v, want the Retry-After capped at MaxBackoff", elapsed)
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	policy := fastRetries
+	policy.AttemptTimeout = 50 * time.Millisecond
+	server, eye, _ := newRetryClient(t, policy)
+	server.Inject("lookupTrait", saurontest.Fault{Latency: time.Second, Times: 1})
+
+	if _, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID); err != nil {
+		t.Fatalf("LookupTrait() error = %v", err)
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("server received %d requests, want the attempt that timed out retried once", got)
+	}
+}
+
+// failingTransport fails the first failures requests with err.
+type failingTransport struct {
+	err      error
+	failures int32
+	attempts atomic.Int32
+}
+
+func (t *failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.attempts.Add(1) <= t.failures {
+		return nil, t.err
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// timeoutError is a net.Error reporting a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryNetworkErrors(t *testing.T) {
+	for _, err := range []error{
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		timeoutError{},
+	} {
+		t.Run(err.Error(), func(t *testing.T) {
+			transport := &failingTransport{err: err, failures: 2}
+			server, eye, _ := newRetryClient(t, fastRetries, WithHTTPClient(&http.Client{Transport: transport}))
+
+			if _, err := eye.LookupTrait(context.Background(), testDataKey, testTraitID); err != nil {
+				t.Fatalf("LookupTrait() error = %v", err)
+			}
+			if got := transport.attempts.Load(); got != 3 {
+				t.Errorf("sent %d attempts, want 3", got)
+			}
+			if got := len(server.Requests()); got != 1 {
+				t.Errorf("server received %d requests, want the last attempt", got)
+			}
+		})
+	}
+}
+
+func TestRetryAfterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "url.Error", err: &url.Error{Op: "Post", URL: "https://sauron.gandalf.network/public/gql", Err: errors.New("EOF")}, want: true},
+		{name: "net.Error timeout", err: fmt.Errorf("failed: %w", timeoutError{}), want: true},
+		{name: "503", err: &graphql.StatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "429", err: &graphql.StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "404", err: &graphql.StatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "GraphQL errors", err: graphql.GraphQLErrors{{Message: "data key not found"}}, want: false},
+		{name: "other", err: errors.New("unable to generate signature"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := retryAfter(tt.err); got != tt.want {
+				t.Errorf("retryAfter(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: lookupTrait_Operation, want: true},
+		{query: `{ __typename }`, want: true},
+		{query: "# Looks up a trait.\nquery lookupTrait { __typename }", want: true},
+		{query: `mutation queryLogs { __typename }`, want: false},
+		{query: `mutation query { __typename }`, want: false},
+		{query: "# query\nmutation disconnect { __typename }", want: false},
+		{query: `query a { __typename } mutation b { __typename }`, want: false},
+		{query: `subscription querySubscription { __typename }`, want: false},
+		{query: `query {`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isQuery(tt.query); got != tt.want {
+			t.Errorf("isQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRetrySkipsMutations(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	eye, err := NewEyeOfSauron(saurontest.PrivateKey, WithEndpoint(server.URL), WithRetryPolicy(fastRetries))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+
+	for _, query := range []string{
+		`mutation disconnect ($dataKey: String!) { disconnect(dataKey: $dataKey) }`,
+		`mutation queryLogs ($dataKey: String!) { queryLogs(dataKey: $dataKey) }`,
+	} {
+		requests.Store(0)
+		req := graphql.NewRequest(query)
+		req.Var("dataKey", testDataKey)
+		if err := eye.run(context.Background(), req, nil); err == nil {
+			t.Fatalf("run(%q) expected an error", query)
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("run(%q) sent %d requests, want 1", query, got)
+		}
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 100, InitialBackoff: 20 * time.Millisecond, Multiplier: 1}
+	server, eye, _ := newRetryClient(t, policy)
+	server.Inject("lookupTrait", saurontest.Fault{StatusCode: http.StatusServiceUnavailable})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := eye.LookupTrait(ctx, testDataKey, testTraitID)
+	elapsed := time.Since(start)
+
+	var statusErr *graphql.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("LookupTrait() error = %v, want the last 503", err)
+	}
+	if elapsed > 300*time.Millisecond {
+		t.Errorf("LookupTrait() took %v, past the 150ms deadline", elapsed)
+	}
+	if got := len(server.Requests()); got < 2 || got > 8 {
+		t.Errorf("server received %d requests, want the attempts that fit in 150ms", got)
+	}
+
+	// A wait past the deadline is not made at all.
+	server.ClearFaults()
+	server.Inject("lookupTrait", saurontest.Fault{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": {"5"}},
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start = time.Now()
+	if _, err := eye.LookupTrait(ctx, testDataKey, testTraitID); err == nil {
+		t.Fatal("LookupTrait() expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("LookupTrait() took %v waiting for a retry past the deadline", elapsed)
+	}
+}
@@ -0,0 +1,150 @@
+package generated
+
+import (
+	"errors"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// typedTrait is implemented by the generated structs holding a trait.
+type typedTrait interface {
+	AsInt() (int64, error)
+	AsFloat() (float64, error)
+	AsBool() (bool, error)
+	AsTime() (time.Time, error)
+	AsEmail() (*mail.Address, error)
+}
+
+// traitAs calls the accessor of trait for kind.
+func traitAs(trait typedTrait, kind TraitKind) (interface{}, error) {
+	switch kind {
+	case TraitKindInt:
+		return trait.AsInt()
+	case TraitKindFloat:
+		return trait.AsFloat()
+	case TraitKindBool:
+		return trait.AsBool()
+	case TraitKindTime:
+		return trait.AsTime()
+	case TraitKindEmail:
+		return trait.AsEmail()
+	}
+	panic("no accessor for " + kind)
+}
+
+func TestTraitAccessors(t *testing.T) {
+	// errWrongKind stands for a TraitValueError without Err.
+	errWrongKind := errors.New("wrong kind")
+	// errParse stands for a TraitValueError with any Err.
+	errParse := errors.New("parse error")
+
+	tests := []struct {
+		name  string
+		label TraitLabel
+		value string
+		kind  TraitKind
+		want  interface{}
+		// err is the error wrapped by the TraitValueError, if any.
+		err error
+	}{
+		{name: "int", label: TraitLabelFollowerCount, value: "42", kind: TraitKindInt, want: int64(42)},
+		{name: "int with spaces", label: TraitLabelTripCount, value: " 7 ", kind: TraitKindInt, want: int64(7)},
+		{name: "int with thousands", label: TraitLabelPostCount, value: "1,234,567", kind: TraitKindInt, want: int64(1234567)},
+		{name: "negative int with thousands", label: TraitLabelOrderCount, value: "-1,000", kind: TraitKindInt, want: int64(-1000)},
+		{name: "int with misplaced commas", label: TraitLabelFollowerCount, value: "1,2,3", kind: TraitKindInt, err: strconv.ErrSyntax},
+		{name: "int with a leading comma", label: TraitLabelFollowerCount, value: ",5", kind: TraitKindInt, err: strconv.ErrSyntax},
+		{name: "int with a long group", label: TraitLabelFollowerCount, value: "1,2345", kind: TraitKindInt, err: strconv.ErrSyntax},
+		{name: "int with a leading long group", label: TraitLabelFollowerCount, value: "1234,567", kind: TraitKindInt, err: strconv.ErrSyntax},
+		{name: "int out of range", label: TraitLabelFollowerCount, value: "9223372036854775808", kind: TraitKindInt, err: strconv.ErrRange},
+		{name: "int of a float label", label: TraitLabelRating, value: "4", kind: TraitKindInt, err: errWrongKind},
+
+		{name: "float", label: TraitLabelRating, value: "4.87", kind: TraitKindFloat, want: 4.87},
+		{name: "float not a number", label: TraitLabelRating, value: "high", kind: TraitKindFloat, err: strconv.ErrSyntax},
+		{name: "float of an int label", label: TraitLabelTripCount, value: "4.5", kind: TraitKindFloat, err: errWrongKind},
+
+		{name: "bool true", label: TraitLabelPrimeSubscriber, value: "true", kind: TraitKindBool, want: true},
+		{name: "bool FALSE", label: TraitLabelPrimeSubscriber, value: "FALSE", kind: TraitKindBool, want: false},
+		{name: "bool yes", label: TraitLabelPrimeSubscriber, value: "Yes", kind: TraitKindBool, want: true},
+		{name: "bool no", label: TraitLabelPrimeSubscriber, value: "no", kind: TraitKindBool, want: false},
+		{name: "bool not a bool", label: TraitLabelPrimeSubscriber, value: "maybe", kind: TraitKindBool, err: strconv.ErrSyntax},
+		{name: "bool of a string label", label: TraitLabelPlan, value: "true", kind: TraitKindBool, err: errWrongKind},
+
+		{name: "time RFC 3339", label: TraitLabelAccountCreatedOn, value: "2020-03-04T05:06:07.89+01:00", kind: TraitKindTime, want: time.Date(2020, 3, 4, 5, 6, 7, 890000000, time.FixedZone("", 3600))},
+		{name: "time without zone", label: TraitLabelAccountCreatedOn, value: "2020-03-04T05:06:07", kind: TraitKindTime, want: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{name: "time with a space", label: TraitLabelAccountCreatedOn, value: "2020-03-04 05:06:07", kind: TraitKindTime, want: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{name: "date", label: TraitLabelAccountCreatedOn, value: "2020-03-04", kind: TraitKindTime, want: time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "US date", label: TraitLabelAccountCreatedOn, value: "03/04/2020", kind: TraitKindTime, want: time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "time in an unknown format", label: TraitLabelAccountCreatedOn, value: "4 March 2020", kind: TraitKindTime, err: errParse},
+		{name: "time of an int label", label: TraitLabelTripCount, value: "2020-03-04", kind: TraitKindTime, err: errWrongKind},
+
+		{name: "email", label: TraitLabelEmail, value: "Jane Doe <jane@example.com>", kind: TraitKindEmail, want: &mail.Address{Name: "Jane Doe", Address: "jane@example.com"}},
+		{name: "email not an address", label: TraitLabelEmail, value: "jane", kind: TraitKindEmail, err: errParse},
+		{name: "email of a string label", label: TraitLabelUsername, value: "jane@example.com", kind: TraitKindEmail, err: errWrongKind},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traits := map[string]typedTrait{
+				"GetTraitsTrait": &GetTraitsTrait{Label: tt.label, Value: tt.value},
+				"LookupTrait":    &LookupTrait{Label: tt.label, Value: tt.value},
+			}
+			for typ, trait := range traits {
+				got, err := traitAs(trait, tt.kind)
+				if tt.err == nil {
+					if err != nil {
+						t.Fatalf("%s.As%s() error = %v", typ, tt.kind, err)
+					}
+					if gotTime, ok := got.(time.Time); ok && gotTime.Equal(tt.want.(time.Time)) {
+						continue
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("%s.As%s() = %v, want %v", typ, tt.kind, got, tt.want)
+					}
+					continue
+				}
+
+				var valueErr *TraitValueError
+				if !errors.As(err, &valueErr) {
+					t.Fatalf("%s.As%s() error = %v, want a TraitValueError", typ, tt.kind, err)
+				}
+				if valueErr.Label != tt.label || valueErr.Value != tt.value || valueErr.Kind != tt.kind {
+					t.Errorf("%s.As%s() error = %+v, want label %s, value %q and kind %s", typ, tt.kind, valueErr, tt.label, tt.value, tt.kind)
+				}
+				switch tt.err {
+				case errWrongKind:
+					if valueErr.Err != nil {
+						t.Errorf("%s.As%s() error wraps %v, want nil for the wrong kind", typ, tt.kind, valueErr.Err)
+					}
+				case errParse:
+					if valueErr.Err == nil {
+						t.Errorf("%s.As%s() error wraps nil, want the parse error", typ, tt.kind)
+					}
+				default:
+					if !errors.Is(err, tt.err) || valueErr.Err != tt.err {
+						t.Errorf("%s.As%s() error wraps %v, want %v", typ, tt.kind, valueErr.Err, tt.err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTraitLabelKind(t *testing.T) {
+	kinds := map[TraitLabel]TraitKind{
+		TraitLabelPrimeSubscriber:  TraitKindBool,
+		TraitLabelRating:           TraitKindFloat,
+		TraitLabelFollowerCount:    TraitKindInt,
+		TraitLabelAccountCreatedOn: TraitKindTime,
+		TraitLabelEmail:            TraitKindEmail,
+		TraitLabelPlan:             TraitKindString,
+		TraitLabel("NEW_LABEL"):    TraitKindString,
+	}
+	for label, want := range kinds {
+		if got := label.Kind(); got != want {
+			t.Errorf("%s.Kind() = %s, want %s", label, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package generated
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/graphql"
+	"github.com/gandalf-network/gandalf-sdk-go/eyeofsauron/saurontest"
+)
+
+// strangerKey is a private key no application of saurontest is registered for.
+const strangerKey = "0x5c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func TestVerify(t *testing.T) {
+	server := saurontest.NewServer(nil)
+	defer server.Close()
+
+	closed := saurontest.NewServer(nil)
+	closed.Close()
+
+	unauthenticated := []saurontest.Error{{Message: "invalid signature", Code: "UNAUTHENTICATED"}}
+	tests := []struct {
+		name       string
+		privateKey string
+		endpoint   string
+		fault      *saurontest.Fault
+		// check reports whether the error is the one expected.
+		check func(error) bool
+	}{
+		{
+			name:       "registered application",
+			privateKey: saurontest.PrivateKey,
+			check:      func(err error) bool { return err == nil },
+		},
+		{
+			name:       "unknown application",
+			privateKey: strangerKey,
+			check: func(err error) bool {
+				var graphqlErrs graphql.GraphQLErrors
+				return errors.Is(err, ErrApplicationNotFound) && !errors.Is(err, ErrSignatureRejected) && errors.As(err, &graphqlErrs)
+			},
+		},
+		{
+			name:       "unauthorized signature",
+			privateKey: saurontest.PrivateKey,
+			fault:      &saurontest.Fault{StatusCode: http.StatusUnauthorized, Errors: unauthenticated},
+			check: func(err error) bool {
+				var statusErr *graphql.StatusError
+				return errors.Is(err, ErrSignatureRejected) && !errors.Is(err, ErrApplicationNotFound) &&
+					errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized
+			},
+		},
+		{
+			name:       "forbidden signature",
+			privateKey: saurontest.PrivateKey,
+			fault:      &saurontest.Fault{StatusCode: http.StatusForbidden},
+			check:      func(err error) bool { return errors.Is(err, ErrSignatureRejected) },
+		},
+		{
+			name:       "server error",
+			privateKey: saurontest.PrivateKey,
+			fault:      &saurontest.Fault{StatusCode: http.StatusInternalServerError},
+			check: func(err error) bool {
+				var statusErr *graphql.StatusError
+				return !errors.Is(err, ErrSignatureRejected) && !errors.Is(err, ErrApplicationNotFound) &&
+					errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusInternalServerError
+			},
+		},
+		{
+			name:       "network error",
+			privateKey: saurontest.PrivateKey,
+			endpoint:   closed.URL,
+			check: func(err error) bool {
+				var urlErr *url.Error
+				return !errors.Is(err, ErrSignatureRejected) && !errors.Is(err, ErrApplicationNotFound) && errors.As(err, &urlErr)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ClearFaults()
+			if tt.fault != nil {
+				server.Inject("getAppByPublicKey", *tt.fault)
+			}
+			endpoint := server.URL
+			if tt.endpoint != "" {
+				endpoint = tt.endpoint
+			}
+
+			eye, err := NewEyeOfSauron(tt.privateKey, WithEndpoint(endpoint), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+			if err != nil {
+				t.Fatalf("NewEyeOfSauron() error = %v", err)
+			}
+			app, err := eye.Verify(context.Background())
+			if !tt.check(err) {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if err == nil && app.PublicKey != saurontest.TestApp.PublicKey {
+				t.Errorf("Verify() = %+v, want %+v", app, saurontest.TestApp)
+			}
+		})
+	}
+}
+
+func TestVerifyUnknownPublicKey(t *testing.T) {
+	server := saurontest.NewServer(nil)
+	defer server.Close()
+
+	privateKey, err := HexToECDSAPrivateKey(saurontest.PrivateKey)
+	if err != nil {
+		t.Fatalf("HexToECDSAPrivateKey() error = %v", err)
+	}
+	// countingSigner hides the PublicKey method of the signer it wraps.
+	eye, err := NewEyeOfSauron("", WithEndpoint(server.URL), WithSigner(&countingSigner{Signer: NewPrivateKeySigner(privateKey)}))
+	if err != nil {
+		t.Fatalf("NewEyeOfSauron() error = %v", err)
+	}
+	if _, err := eye.Verify(context.Background()); err == nil || errors.Is(err, ErrApplicationNotFound) {
+		t.Errorf("Verify() error = %v, want the public key reported as unknown", err)
+	}
+	if got := len(server.Requests()); got != 0 {
+		t.Errorf("server received %d requests, want none without a public key", got)
+	}
+}
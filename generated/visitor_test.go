@@ -0,0 +1,150 @@
+package generated
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingVisitor records the method called for each metadata it visits.
+type recordingVisitor struct {
+	visits []string
+	err    error
+}
+
+func (r *recordingVisitor) visit(method, title string) error {
+	r.visits = append(r.visits, method+" "+title)
+	return r.err
+}
+
+func (r *recordingVisitor) VisitAmazon(m *AmazonActivityMetadata) error {
+	return r.visit("VisitAmazon", m.ProductName)
+}
+
+func (r *recordingVisitor) VisitBooking(m *BookingActivityMetadata) error {
+	return r.visit("VisitBooking", m.BookingID)
+}
+
+func (r *recordingVisitor) VisitInstacart(m *InstacartActivityMetadata) error {
+	return r.visit("VisitInstacart", m.Retailer)
+}
+
+func (r *recordingVisitor) VisitNetflix(m *NetflixActivityMetadata) error {
+	return r.visit("VisitNetflix", m.Title)
+}
+
+func (r *recordingVisitor) VisitPlaystation(m *PlaystationActivityMetadata) error {
+	return r.visit("VisitPlaystation", m.Title)
+}
+
+func (r *recordingVisitor) VisitUber(m *UberActivityMetadata) error {
+	return r.visit("VisitUber", m.City)
+}
+
+func (r *recordingVisitor) VisitUberEats(m *UberEatsActivityMetadata) error {
+	return r.visit("VisitUberEats", m.Restaurant)
+}
+
+func (r *recordingVisitor) VisitYoutube(m *YoutubeActivityMetadata) error {
+	return r.visit("VisitYoutube", m.Title)
+}
+
+// decodeActivities decodes a getActivity response holding the given metadata.
+func decodeActivities(t *testing.T, metadata ...string) []GetActivityActivityResponseDataActivity {
+	t.Helper()
+	data := make([]string, len(metadata))
+	for i, m := range metadata {
+		data[i] = fmt.Sprintf(`{"id":"a%d","metadata":%s}`, i+1, m)
+	}
+	var resp getActivityResponse
+	body := `{"getActivity":{"data":[` + strings.Join(data, ",") + `],"limit":10,"total":8,"page":1}}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return resp.GetActivity.Data
+}
+
+func TestAcceptMetadata(t *testing.T) {
+	activities := decodeActivities(t,
+		`{"__typename":"NetflixActivityMetadata","title":"Dark","date":"01/02/2024","lastPlayedAt":"01/03/2024"}`,
+		`{"__typename":"AmazonActivityMetadata","productName":"Kindle","date":"03/04/2024","totalCost":"99.99"}`,
+		`{"__typename":"UberActivityMetadata","beginTripTime":"2024-07-08T09:10:11Z","dropoffTime":"2024-07-08T09:40:00Z","city":"Austin","UberActivityMetadataStatus":"COMPLETED"}`,
+		`{"__typename":"BookingActivityMetadata","bookingID":"B-1","price":"EUR 120.50"}`,
+		`{"__typename":"YoutubeActivityMetadata","title":"Gophers","date":"02/13/2024","contentType":"VIDEO"}`,
+		`{"__typename":"InstacartActivityMetadata","retailer":"Costco","dateOrdered":"05/06/2024","dateDelivered":"05/07/2024"}`,
+		`{"__typename":"PlaystationActivityMetadata","title":"Astro Bot","lastPlayedAt":"09/10/2024"}`,
+		`{"__typename":"UberEatsActivityMetadata","date":"11/12/2024","restaurant":"Tacodeli","status":"SUCCESS"}`,
+	)
+
+	visitor := &recordingVisitor{}
+	for _, activity := range activities {
+		if err := activity.AcceptMetadata(visitor); err != nil {
+			t.Fatalf("AcceptMetadata(%s): %v", activity.Id, err)
+		}
+	}
+	want := []string{
+		"VisitNetflix Dark",
+		"VisitAmazon Kindle",
+		"VisitUber Austin",
+		"VisitBooking B-1",
+		"VisitYoutube Gophers",
+		"VisitInstacart Costco",
+		"VisitPlaystation Astro Bot",
+		"VisitUberEats Tacodeli",
+	}
+	if !reflect.DeepEqual(visitor.visits, want) {
+		t.Errorf("visits = %q, want %q", visitor.visits, want)
+	}
+
+	// Accept is also reachable through the ActivityMetadata of the
+	// normalised activity, and visits the same metadata.
+	visitor = &recordingVisitor{}
+	if err := activities[0].ToActivity().Metadata.Accept(visitor); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	if want := []string{"VisitNetflix Dark"}; !reflect.DeepEqual(visitor.visits, want) {
+		t.Errorf("visits = %q, want %q", visitor.visits, want)
+	}
+}
+
+func TestAcceptMetadataVisitorError(t *testing.T) {
+	activities := decodeActivities(t, `{"__typename":"PlaystationActivityMetadata","title":"Astro Bot","lastPlayedAt":"09/10/2024"}`)
+
+	visitErr := errors.New("visit failed")
+	visitor := &recordingVisitor{err: visitErr}
+	if err := activities[0].AcceptMetadata(visitor); !errors.Is(err, visitErr) {
+		t.Errorf("AcceptMetadata() = %v, want %v", err, visitErr)
+	}
+}
+
+func TestAcceptMetadataWithoutMetadata(t *testing.T) {
+	activities := decodeActivities(t, `null`)
+
+	visitor := &recordingVisitor{}
+	err := activities[0].AcceptMetadata(visitor)
+	if err == nil || !strings.Contains(err.Error(), "unsupported activity metadata") {
+		t.Errorf("AcceptMetadata() = %v, want an unsupported activity metadata error", err)
+	}
+	if len(visitor.visits) != 0 {
+		t.Errorf("visits = %q, want none", visitor.visits)
+	}
+
+	lookup := LookupActivity{Id: "a1"}
+	if err := lookup.AcceptMetadata(visitor); err == nil {
+		t.Error("LookupActivity.AcceptMetadata() without metadata succeeded")
+	}
+}
+
+func TestUnknownActivityMetadata(t *testing.T) {
+	// A source added to the schema after the client was generated fails to
+	// decode rather than reaching a visitor without a method for it.
+	var resp getActivityResponse
+	body := `{"getActivity":{"data":[{"id":"a1","metadata":{"__typename":"SpotifyActivityMetadata","title":"Song"}}],"limit":10,"total":1,"page":1}}`
+	err := json.Unmarshal([]byte(body), &resp)
+	if err == nil || !strings.Contains(err.Error(), `unexpected concrete type`) || !strings.Contains(err.Error(), "SpotifyActivityMetadata") {
+		t.Errorf("Unmarshal() = %v, want an unexpected concrete type error", err)
+	}
+}